@@ -5,6 +5,7 @@ import (
 	"github.com/gruntwork-io/terratest/modules/k8s"
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"path/filepath"
 	"strings"
@@ -39,11 +40,13 @@ func TestConfigMapBasic(t *testing.T) {
 		"env.configMap.KEY_2": "bbb",
 	}
 	//releaseName, configMap := givenASecretTemplateWithHelm(t, require, defaultValues)
-	_, configMap := givenAConfigMapTemplateWithHelm(t, require, values)
+	releaseName, configMap := givenAConfigMapTemplateWithHelm(t, require, values)
 
-	require.Equal("app-env-config-map", configMap.Name)
+	require.Equal(releaseName+"-chart-test-env", configMap.Name)
 	require.Empty(configMap.Annotations)
-	require.Empty(configMap.Labels)
+	require.Equal("chart-test", configMap.Labels["app.kubernetes.io/name"])
+	require.Equal("Helm", configMap.Labels["app.kubernetes.io/managed-by"])
+	require.Equal("chart-test-0.1.0", configMap.Labels["helm.sh/chart"])
 	//require.Equal(v1.SecretType("Opaque"), configMap.Type)
 
 	require.Len(configMap.Data, 2)
@@ -52,3 +55,117 @@ func TestConfigMapBasic(t *testing.T) {
 	require.Equal("bbb", configMap.Data["KEY_2"])
 
 }
+
+func TestConfigMapExplicitName(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.configMap.KEY_1":  "aaa",
+		"appEnvConfigMap.name": "app-env-config-map",
+	}
+	_, configMap := givenAConfigMapTemplateWithHelm(t, require, values)
+
+	require.Equal("app-env-config-map", configMap.Name)
+}
+
+func givenConfigFilesRenderedWithHelm(t *testing.T, require *require.Assertions, template string, values map[string]string) (string, string) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      values,
+		ValuesFiles:    []string{"values-config-files.yaml"},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	return releaseName, helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{template})
+}
+
+func TestConfigFiles(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"configFiles.annotations.reload": "true",
+	}
+	releaseName, output := givenConfigFilesRenderedWithHelm(t, require, "templates/configfiles.yaml", values)
+
+	var configFiles v1.ConfigMap
+	helm.UnmarshalK8SYaml(t, output, &configFiles)
+	require.Equal(releaseName+"-chart-test-config", configFiles.Name)
+	require.Equal(map[string]string{"reload": "true"}, configFiles.Annotations)
+	require.Equal("chart-test", configFiles.Labels["app.kubernetes.io/name"])
+	require.Len(configFiles.Data, 2)
+	require.Equal("server:\n  port: 8000\nspring:\n  application:\n    name: "+releaseName, configFiles.Data["application.yaml"])
+	require.Contains(configFiles.Data["nginx.conf"], "listen 8000;")
+	require.Equal(map[string][]byte{
+		"keystore.p12": []byte("0000"),
+		"logo.png":     []byte("\x89PNG\r\n\x1a\n"),
+	}, configFiles.BinaryData)
+}
+
+func TestConfigFilesNotInTheEnvConfigMap(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.configMap.KEY_1": "aaa",
+	}
+	releaseName, output := givenConfigFilesRenderedWithHelm(t, require, "templates/configmap.yaml", values)
+
+	require.NotContains(output, "\n---\n")
+	var configMap v1.ConfigMap
+	helm.UnmarshalK8SYaml(t, output, &configMap)
+	require.Equal(releaseName+"-chart-test-env", configMap.Name)
+	require.Equal(map[string]string{"KEY_1": "aaa"}, configMap.Data)
+}
+
+func TestConfigFilesMountedInDeployment(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	releaseName, output := givenConfigFilesRenderedWithHelm(t, require, "templates/deployment.yaml", map[string]string{})
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	require.Contains(deployment.Spec.Template.Spec.Volumes, v1.Volume{Name: "config-files", VolumeSource: v1.VolumeSource{
+		ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: releaseName + "-chart-test-config"}},
+	}})
+	require.Equal([]v1.VolumeMount{{Name: "config-files", MountPath: "/etc/app", ReadOnly: true}}, deployment.Spec.Template.Spec.Containers[0].VolumeMounts)
+	require.NotEmpty(deployment.Spec.Template.Annotations["checksum/config"])
+}
+
+func TestConfigFilesMountedWithSubPath(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"configFiles.mountPath": "/etc/nginx/",
+		"configFiles.subPath":   "true",
+	}
+	_, output := givenConfigFilesRenderedWithHelm(t, require, "templates/deployment.yaml", values)
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	require.Equal([]v1.VolumeMount{
+		{Name: "config-files", MountPath: "/etc/nginx/application.yaml", SubPath: "application.yaml", ReadOnly: true},
+		{Name: "config-files", MountPath: "/etc/nginx/keystore.p12", SubPath: "keystore.p12", ReadOnly: true},
+		{Name: "config-files", MountPath: "/etc/nginx/logo.png", SubPath: "logo.png", ReadOnly: true},
+		{Name: "config-files", MountPath: "/etc/nginx/nginx.conf", SubPath: "nginx.conf", ReadOnly: true},
+	}, deployment.Spec.Template.Spec.Containers[0].VolumeMounts)
+}
+
+func TestWithoutConfigFiles(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.configMap.KEY_1": "aaa",
+	}
+	releaseName, configMap := givenAConfigMapTemplateWithHelm(t, require, values)
+
+	require.Equal(releaseName+"-chart-test-env", configMap.Name)
+	require.Nil(configMap.BinaryData)
+}
@@ -6,6 +6,7 @@ import (
 	batchV1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -108,6 +109,19 @@ func TestCronJobCustomServiceAccountApi21(t *testing.T) {
 	assertions.Equal("customSA", cronJob.Spec.JobTemplate.Spec.Template.Spec.ServiceAccountName)
 }
 
+func TestCronJobCreatedServiceAccountApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"serviceAccount.create": "true",
+		"serviceAccount.name":   "cron-sa",
+	}
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, values)
+
+	assertions.Equal("cron-sa", cronJob.Spec.JobTemplate.Spec.Template.Spec.ServiceAccountName)
+}
+
 func TestCronJobMetricsDisabledApi21(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -148,6 +162,102 @@ func TestCronJobMetricsEnabledApi21(t *testing.T) {
 	}
 }
 
+func TestCronJobMetricsScrapeAnnotationsDisabledApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"metrics.enabled":           "true",
+		"metrics.scrapeAnnotations": "false",
+	}
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, values)
+
+	for _, annotationKey := range []string{"prometheus.io/scrape", "prometheus.io/port", "prometheus.io/path"} {
+		assertions.Empty(cronJob.Spec.JobTemplate.Spec.Template.Annotations[annotationKey], annotationKey+" should be not defined")
+	}
+}
+
+func TestCronJobRestrictedSecurityPresetApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"securityPreset":            "restricted",
+		"initContainers[0].name":    "migrate",
+		"initContainers[0].args[0]": "migrate",
+	}
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, values)
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assertions.True(*podSpec.SecurityContext.RunAsNonRoot)
+	assertions.Equal(v1.SeccompProfileTypeRuntimeDefault, podSpec.SecurityContext.SeccompProfile.Type)
+	assertions.Equal([]v1.Volume{{Name: "tmp", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}, podSpec.Volumes)
+
+	for _, container := range []v1.Container{podSpec.Containers[0], podSpec.InitContainers[0]} {
+		assertions.False(*container.SecurityContext.AllowPrivilegeEscalation)
+		assertions.True(*container.SecurityContext.ReadOnlyRootFilesystem)
+		assertions.Equal([]v1.Capability{"ALL"}, container.SecurityContext.Capabilities.Drop)
+		assertions.Equal([]v1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}}, container.VolumeMounts)
+	}
+}
+
+func TestCronJobReadOnlyRootFilesystemDisabledApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"securityPreset":                         "restricted",
+		"securityContext.readOnlyRootFilesystem": "false",
+	}
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, values)
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assertions.False(*podSpec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	assertions.Empty(podSpec.Containers[0].VolumeMounts)
+	assertions.Empty(podSpec.Volumes)
+}
+
+func TestCronJobCommonAndPodLabelsApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"commonLabels.cost-center":    "team-a",
+		"podLabels.sidecar-injection": "enabled",
+	}
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, values)
+
+	assertions.Equal("team-a", cronJob.Labels["cost-center"])
+	assertions.Equal("team-a", cronJob.Spec.JobTemplate.Labels["cost-center"])
+	assertions.Equal("chart-test", cronJob.Spec.JobTemplate.Labels["app.kubernetes.io/name"])
+	assertions.NotContains(cronJob.Spec.JobTemplate.Labels, "sidecar-injection")
+
+	podLabels := cronJob.Spec.JobTemplate.Spec.Template.Labels
+	assertions.Equal("team-a", podLabels["cost-center"])
+	assertions.Equal("enabled", podLabels["sidecar-injection"])
+	assertions.Equal("cronjob", podLabels["app.kubernetes.io/component"])
+	assertions.NotContains(podLabels, "app.kubernetes.io/name")
+}
+
+func TestCronJobPodsNotSelectedByTheServiceApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, map[string]string{})
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+	options := &helm.Options{
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/service.yaml"})
+	var service v1.Service
+	helm.UnmarshalK8SYaml(t, output, &service)
+
+	assertions.NotEmpty(service.Spec.Selector)
+	assertions.False(labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(cronJob.Spec.JobTemplate.Spec.Template.Labels)))
+}
+
 func TestCronJobDefaultIpPoolDisabledApi21(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -371,7 +481,7 @@ func TestCronJobWithSecretEnvVarsApi21(t *testing.T) {
 		envKey := strings.TrimPrefix(key, "env.secret.")
 		envVar, err := findByKey(envKey, envVars)
 		assertions.NoError(err)
-		assertions.Equal("app-env-secret", envVar.ValueFrom.SecretKeyRef.Name)
+		assertions.Equal("helm-basic-chart-test-env", envVar.ValueFrom.SecretKeyRef.Name)
 		assertions.Equal(envKey, envVar.ValueFrom.SecretKeyRef.Key)
 	}
 }
@@ -396,11 +506,43 @@ func TestCronJobWithConfigMapEnvVarsApi21(t *testing.T) {
 		envKey := strings.TrimPrefix(key, "env.configMap.")
 		envVar, err := findByKey(envKey, envVars)
 		assertions.NoError(err)
-		assertions.Equal("app-env-config-map", envVar.ValueFrom.ConfigMapKeyRef.Name)
+		assertions.Equal("helm-basic-chart-test-env", envVar.ValueFrom.ConfigMapKeyRef.Name)
 		assertions.Equal(envKey, envVar.ValueFrom.ConfigMapKeyRef.Key)
 	}
 }
 
+func TestCronJobWithDownwardApiEnvVarsAndEnvFromApi21(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"env.fieldRef.POD_NAME":             "metadata.name",
+		"env.resourceFieldRef.MEMORY_LIMIT": "limits.memory",
+		"env.secretKeyRef.API_TOKEN.name":   "external-api",
+		"envFrom[0].secretRef.name":         "app-credentials",
+	}
+
+	_, cronJob := givenACronJobTemplateWithHelmApi21(t, assertions, values)
+
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+
+	podName, err := findByKey("POD_NAME", container.Env)
+	assertions.NoError(err)
+	assertions.Equal("metadata.name", podName.ValueFrom.FieldRef.FieldPath)
+
+	memoryLimit, err := findByKey("MEMORY_LIMIT", container.Env)
+	assertions.NoError(err)
+	assertions.Equal("limits.memory", memoryLimit.ValueFrom.ResourceFieldRef.Resource)
+
+	apiToken, err := findByKey("API_TOKEN", container.Env)
+	assertions.NoError(err)
+	assertions.Equal("external-api", apiToken.ValueFrom.SecretKeyRef.Name)
+	assertions.Equal("API_TOKEN", apiToken.ValueFrom.SecretKeyRef.Key)
+
+	assertions.Len(container.EnvFrom, 1)
+	assertions.Equal("app-credentials", container.EnvFrom[0].SecretRef.Name)
+}
+
 func TestCronJobCustomValuesApi21(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -0,0 +1,132 @@
+package daemonset
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDaemonSetBasic(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	daemonSet, releaseName := givenADaemonSetTemplateWithHelm(t, assertions, map[string]string{})
+
+	assertions.Equal(releaseName+"-chart-test", daemonSet.Name)
+
+	assertions.Equal(appsv1.RollingUpdateDaemonSetStrategyType, daemonSet.Spec.UpdateStrategy.Type)
+	assertions.Equal(int32(1), daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.IntVal)
+	assertions.Equal(int32(0), daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxSurge.IntVal)
+	assertions.Equal(int32(0), daemonSet.Spec.MinReadySeconds)
+	assertions.Equal(int32(3), *daemonSet.Spec.RevisionHistoryLimit)
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":     "chart-test",
+		"app.kubernetes.io/instance": releaseName,
+	}
+	assertions.Equal(labels, daemonSet.Spec.Selector.MatchLabels)
+	for key, value := range labels {
+		assertions.Equal(value, daemonSet.Spec.Template.Labels[key])
+	}
+	assertions.Equal("Helm", daemonSet.Spec.Template.Labels["app.kubernetes.io/managed-by"])
+
+	podSpec := daemonSet.Spec.Template.Spec
+	assertions.False(podSpec.HostNetwork)
+	assertions.False(podSpec.HostPID)
+	assertions.Empty(podSpec.DNSPolicy)
+	assertions.Empty(podSpec.PriorityClassName)
+
+	assertions.Equal(1, len(podSpec.Containers))
+	container := podSpec.Containers[0]
+	assertions.Equal("nginx:latest", container.Image)
+	assertions.Contains(container.Ports, v1.ContainerPort{Name: "http", ContainerPort: 8000, Protocol: "TCP"})
+	assertions.Contains(container.Ports, v1.ContainerPort{Name: "health-check", ContainerPort: 9000, Protocol: "TCP"})
+	assertions.Equal("/health", container.LivenessProbe.HTTPGet.Path)
+}
+
+func givenADaemonSetTemplateWithHelm(t *testing.T, assertions *require.Assertions, values map[string]string) (daemonSet appsv1.DaemonSet, releaseName string) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName = "helm-basic"
+	assertions.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/daemonset.yaml"})
+
+	helm.UnmarshalK8SYaml(t, output, &daemonSet)
+	return daemonSet, releaseName
+}
+
+func TestDaemonSetNodeAgent(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"daemonSet.updateStrategy.rollingUpdate.maxUnavailable": "25%",
+		"daemonSet.updateStrategy.rollingUpdate.maxSurge":       "1",
+		"daemonSet.minReadySeconds":                             "5",
+		"daemonSet.revisionHistoryLimit":                        "10",
+		"daemonSet.hostNetwork":                                 "true",
+		"daemonSet.hostPID":                                     "true",
+		"daemonSet.dnsPolicy":                                   "ClusterFirstWithHostNet",
+		"daemonSet.priorityClassName":                           "system-node-critical",
+	}
+	daemonSet, _ := givenADaemonSetTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal("25%", daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.StrVal)
+	assertions.Equal(int32(1), daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxSurge.IntVal)
+	assertions.Equal(int32(5), daemonSet.Spec.MinReadySeconds)
+	assertions.Equal(int32(10), *daemonSet.Spec.RevisionHistoryLimit)
+
+	podSpec := daemonSet.Spec.Template.Spec
+	assertions.True(podSpec.HostNetwork)
+	assertions.True(podSpec.HostPID)
+	assertions.Equal(v1.DNSClusterFirstWithHostNet, podSpec.DNSPolicy)
+	assertions.Equal("system-node-critical", podSpec.PriorityClassName)
+}
+
+func TestDaemonSetOnDeleteStrategy(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"daemonSet.updateStrategy.type": "OnDelete",
+	}
+	daemonSet, _ := givenADaemonSetTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal(appsv1.OnDeleteDaemonSetStrategyType, daemonSet.Spec.UpdateStrategy.Type)
+	assertions.Nil(daemonSet.Spec.UpdateStrategy.RollingUpdate)
+}
+
+func TestDaemonSetInvalidStrategy(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"daemonSet.updateStrategy.type": "Recreate",
+	}
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/daemonset.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid updateStrategy type, must be one of (RollingUpdate,OnDelete)")
+}
@@ -42,12 +42,16 @@ func TestDeploymentBasic(t *testing.T) {
 		"app.kubernetes.io/instance": releaseName,
 	}
 	assertions.Equal(labels, deployment.Spec.Selector.MatchLabels)
-	assertions.Equal(labels, deployment.Spec.Template.Labels)
+	for key, value := range labels {
+		assertions.Equal(value, deployment.Spec.Template.Labels[key])
+	}
+	assertions.Equal("Helm", deployment.Spec.Template.Labels["app.kubernetes.io/managed-by"])
 	assertions.Equal(annotations, deployment.Spec.Template.Annotations)
 
 	assertions.Equal(1, len(deployment.Spec.Template.Spec.ImagePullSecrets))
 	assertions.Equal("myregistrykey", deployment.Spec.Template.Spec.ImagePullSecrets[0].Name)
 	assertions.Equal("default", deployment.Spec.Template.Spec.ServiceAccountName)
+	assertions.Nil(deployment.Spec.Template.Spec.AutomountServiceAccountToken)
 
 	assertions.Empty(deployment.Spec.Template.Spec.Volumes)
 	assertions.Empty(deployment.Spec.Template.Spec.InitContainers)
@@ -118,6 +122,32 @@ func givenADeploymentTemplateWithHelm(t *testing.T, assertions *require.Assertio
 	return deployment, releaseName, namespaceName
 }
 
+func TestDeploymentCommonAndPodLabels(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"commonLabels.cost-center":    "team-a",
+		"podLabels.sidecar-injection": "enabled",
+	}
+	deployment, releaseName, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal("team-a", deployment.Labels["cost-center"])
+	assertions.NotContains(deployment.Labels, "sidecar-injection")
+
+	podLabels := deployment.Spec.Template.Labels
+	assertions.Equal("team-a", podLabels["cost-center"])
+	assertions.Equal("enabled", podLabels["sidecar-injection"])
+	assertions.Equal("chart-test-0.1.0", podLabels["helm.sh/chart"])
+	assertions.Equal("1.16.0", podLabels["app.kubernetes.io/version"])
+
+	selectorLabels := map[string]string{
+		"app.kubernetes.io/name":     "chart-test",
+		"app.kubernetes.io/instance": releaseName,
+	}
+	assertions.Equal(selectorLabels, deployment.Spec.Selector.MatchLabels)
+}
+
 func TestDeploymentCustomServiceAccount(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -130,6 +160,34 @@ func TestDeploymentCustomServiceAccount(t *testing.T) {
 	assertions.Equal("customSA", deployment.Spec.Template.Spec.ServiceAccountName)
 }
 
+func TestDeploymentAutoscalingEnabled(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"replicaCount":        "5",
+		"autoscaling.enabled": "true",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	assertions.Nil(deployment.Spec.Replicas)
+}
+
+func TestDeploymentCreatedServiceAccount(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"global.serviceAccountName":                   "customSA",
+		"serviceAccount.create":                       "true",
+		"serviceAccount.automountServiceAccountToken": "false",
+	}
+	deployment, releaseName, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal(releaseName+"-chart-test", deployment.Spec.Template.Spec.ServiceAccountName)
+	assertions.False(*deployment.Spec.Template.Spec.AutomountServiceAccountToken)
+}
+
 func TestDeploymentMetricsDisabled(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -392,7 +450,7 @@ func TestDeploymentWithSecretEnvVars(t *testing.T) {
 		envKey := strings.TrimPrefix(key, "env.secret.")
 		envVar, err := findByKey(envKey, envVars)
 		assertions.NoError(err)
-		assertions.Equal("app-env-secret", envVar.ValueFrom.SecretKeyRef.Name)
+		assertions.Equal("helm-basic-chart-test-env", envVar.ValueFrom.SecretKeyRef.Name)
 		assertions.Equal(envKey, envVar.ValueFrom.SecretKeyRef.Key)
 	}
 }
@@ -426,11 +484,76 @@ func TestDeploymentWithConfigMapEnvVars(t *testing.T) {
 		envKey := strings.TrimPrefix(key, "env.configMap.")
 		envVar, err := findByKey(envKey, envVars)
 		assertions.NoError(err)
-		assertions.Equal("app-env-config-map", envVar.ValueFrom.ConfigMapKeyRef.Name)
+		assertions.Equal("helm-basic-chart-test-env", envVar.ValueFrom.ConfigMapKeyRef.Name)
 		assertions.Equal(envKey, envVar.ValueFrom.ConfigMapKeyRef.Key)
 	}
 }
 
+func TestDeploymentChecksumAnnotations(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"env.configMap.FEATURE_FLAG": "on",
+		"env.secret.DB_PASSWORD":     "pwd",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+	annotations := deployment.Spec.Template.Annotations
+	assertions.Len(annotations["checksum/config"], 64)
+	assertions.Len(annotations["checksum/secret"], 64)
+
+	sameDeployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, map[string]string{
+		"env.configMap.FEATURE_FLAG": "on",
+		"env.secret.DB_PASSWORD":     "pwd",
+		"env.normal.LOG_LEVEL_APP":   "DEBUG",
+	})
+	assertions.Equal(annotations["checksum/config"], sameDeployment.Spec.Template.Annotations["checksum/config"])
+	assertions.Equal(annotations["checksum/secret"], sameDeployment.Spec.Template.Annotations["checksum/secret"])
+
+	changedDeployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, map[string]string{
+		"env.configMap.FEATURE_FLAG": "off",
+		"env.secret.DB_PASSWORD":     "pwd",
+	})
+	assertions.NotEqual(annotations["checksum/config"], changedDeployment.Spec.Template.Annotations["checksum/config"])
+	assertions.Equal(annotations["checksum/secret"], changedDeployment.Spec.Template.Annotations["checksum/secret"])
+}
+
+func TestDeploymentChecksumAnnotationsDisabled(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"checksumAnnotations":        "false",
+		"env.configMap.FEATURE_FLAG": "on",
+		"env.secret.DB_PASSWORD":     "pwd",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	assertions.NotContains(deployment.Spec.Template.Annotations, "checksum/config")
+	assertions.NotContains(deployment.Spec.Template.Annotations, "checksum/secret")
+}
+
+func TestDeploymentWithExplicitEnvObjectNames(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"appEnvSecret.name":    "app-env-secret",
+		"appEnvConfigMap.name": "app-env-config-map",
+		"env.secret.PASSWORD":  "pwd",
+		"env.configMap.LEVEL":  "debug",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	envVars := deployment.Spec.Template.Spec.Containers[0].Env
+	secretEnvVar, err := findByKey("PASSWORD", envVars)
+	assertions.NoError(err)
+	assertions.Equal("app-env-secret", secretEnvVar.ValueFrom.SecretKeyRef.Name)
+	configMapEnvVar, err := findByKey("LEVEL", envVars)
+	assertions.NoError(err)
+	assertions.Equal("app-env-config-map", configMapEnvVar.ValueFrom.ConfigMapKeyRef.Name)
+}
+
 func TestDeploymentWithTerminationGracePeriod(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -495,6 +618,82 @@ func TestDeploymentWithoutVault(t *testing.T) {
 	}
 }
 
+func TestDeploymentWithVaultCustomMountPathAndRole(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"vault.mount":        "secret",
+		"vault.path":         "teams/payments",
+		"vault.role":         "payments",
+		"env.vault.AWS_TEST": "internal/aws#AWS_TEST",
+	}
+
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	envVars := deployment.Spec.Template.Spec.Containers[0].Env
+	assertions.Contains(envVars, v1.EnvVar{Name: "AWS_TEST", Value: "vault:secret/data/teams/payments/internal/aws#AWS_TEST"})
+	assertions.Equal("payments", deployment.Spec.Template.Annotations["vault.security.banzaicloud.io/vault-role"])
+	assertions.NotContains(deployment.Spec.Template.Annotations, "vault.hashicorp.com/agent-inject")
+}
+
+func TestDeploymentWithVaultAgent(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	const vaultAddr = "https://vault-dev"
+	values := map[string]string{
+		"global.vaultAddress":   vaultAddr,
+		"vault.injector":        "vault-agent",
+		"env.vault.AWS_KEY":     "internal/aws#AWS_KEY",
+		"env.vault.AWS_SECRET":  "internal/aws#secret.key",
+		"env.vault.DB_PASSWORD": "db",
+	}
+
+	deployment, _, namespaceName := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	for _, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+		assertions.NotContains(envVar.Value, "vault:")
+	}
+
+	annotations := deployment.Spec.Template.Annotations
+	assertions.NotContains(annotations, "vault.security.banzaicloud.io/vault-addr")
+	assertions.Equal("true", annotations["vault.hashicorp.com/agent-inject"])
+	assertions.Equal(vaultAddr, annotations["vault.hashicorp.com/service"])
+	assertions.Equal(namespaceName, annotations["vault.hashicorp.com/role"])
+
+	awsPath := "k8s/data/" + namespaceName + "/internal/aws"
+	assertions.Equal(awsPath, annotations["vault.hashicorp.com/agent-inject-secret-internal-aws"])
+	assertions.Equal(`{{- with secret "`+awsPath+`" -}}
+export AWS_KEY="{{ index .Data.data "AWS_KEY" }}"
+export AWS_SECRET="{{ index .Data.data "secret.key" }}"
+{{- end }}`, annotations["vault.hashicorp.com/agent-inject-template-internal-aws"])
+
+	dbPath := "k8s/data/" + namespaceName + "/db"
+	assertions.Equal(dbPath, annotations["vault.hashicorp.com/agent-inject-secret-db"])
+	assertions.Contains(annotations["vault.hashicorp.com/agent-inject-template-db"], `export DB_PASSWORD="{{ index .Data.data "DB_PASSWORD" }}"`)
+}
+
+func TestDeploymentWithInvalidVaultInjector(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"vault.injector":     "external-secrets",
+			"env.vault.AWS_TEST": "internal/aws#AWS_TEST",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid vault.injector, must be one of (bank-vaults,vault-agent)")
+}
+
 func TestExtraInitContainersAndVolumes(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -541,6 +740,199 @@ func TestExtraInitContainersAndVolumes(t *testing.T) {
 	assertions.Equal(volume, volumes[0])
 }
 
+func TestStructuredInitContainers(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	assertions.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+		ValuesFiles:    []string{"values-init-containers.yaml"},
+		SetValues: map[string]string{
+			"resources.requests.cpu": "200m",
+		},
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/deployment.yaml"})
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	volumeMount := v1.VolumeMount{Name: "data", MountPath: "/data"}
+	assertions.Equal([]v1.Volume{{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}, deployment.Spec.Template.Spec.Volumes)
+	assertions.Equal([]v1.VolumeMount{volumeMount}, deployment.Spec.Template.Spec.Containers[0].VolumeMounts)
+
+	initContainers := deployment.Spec.Template.Spec.InitContainers
+	assertions.Len(initContainers, 2)
+
+	migrate := initContainers[0]
+	assertions.Equal("migrate", migrate.Name)
+	assertions.Equal("nginx:latest", migrate.Image)
+	assertions.Equal(v1.PullIfNotPresent, migrate.ImagePullPolicy)
+	assertions.Equal([]string{"/app/migrate"}, migrate.Command)
+	assertions.Equal([]string{"up"}, migrate.Args)
+	assertions.Equal(deployment.Spec.Template.Spec.Containers[0].Env, migrate.Env)
+	assertions.Equal(resource.MustParse("200m"), migrate.Resources.Requests[v1.ResourceCPU])
+	assertions.Equal([]v1.VolumeMount{volumeMount}, migrate.VolumeMounts)
+
+	waitForDb := initContainers[1]
+	assertions.Equal("wait-for-db", waitForDb.Name)
+	assertions.Equal("busybox:1.36", waitForDb.Image)
+	assertions.Equal(v1.PullAlways, waitForDb.ImagePullPolicy)
+	assertions.Equal([]v1.EnvVar{{Name: "DB_HOST", Value: releaseName + "-db"}}, waitForDb.Env)
+	assertions.Empty(waitForDb.Resources.Requests)
+	assertions.Equal(resource.MustParse("32Mi"), waitForDb.Resources.Limits[v1.ResourceMemory])
+	assertions.Equal("/tmp", waitForDb.WorkingDir)
+	assertions.Empty(waitForDb.VolumeMounts)
+}
+
+func TestInitContainerWithoutName(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"initContainers[0].name":       "migrate",
+			"initContainers[0].command[0]": "/app/migrate",
+			"initContainers[1].image":      "busybox",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid initContainers[1], name must be set")
+}
+
+func TestInitContainerWithoutCommand(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"initContainers[0].name": "migrate",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid initContainer migrate, command or args must be set when it runs the image of the application")
+}
+
+func TestDeploymentWithoutSecurityContext(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, map[string]string{})
+
+	assertions.Nil(deployment.Spec.Template.Spec.SecurityContext)
+	assertions.Nil(deployment.Spec.Template.Spec.Containers[0].SecurityContext)
+}
+
+func TestDeploymentRestrictedSecurityPreset(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"securityPreset":               "restricted",
+		"podSecurityContext.runAsUser": "1000",
+		"initContainers[0].name":       "migrate",
+		"initContainers[0].args[0]":    "migrate",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	runAsNonRoot := true
+	runAsUser := int64(1000)
+	assertions.Equal(&v1.PodSecurityContext{
+		RunAsNonRoot:   &runAsNonRoot,
+		RunAsUser:      &runAsUser,
+		SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+	}, deployment.Spec.Template.Spec.SecurityContext)
+
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := true
+	securityContext := &v1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+	}
+	tmpVolumeMount := v1.VolumeMount{Name: "tmp", MountPath: "/tmp"}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assertions.Equal(securityContext, container.SecurityContext)
+	assertions.Equal([]v1.VolumeMount{tmpVolumeMount}, container.VolumeMounts)
+
+	initContainer := deployment.Spec.Template.Spec.InitContainers[0]
+	assertions.Equal(securityContext, initContainer.SecurityContext)
+	assertions.Equal([]v1.VolumeMount{tmpVolumeMount}, initContainer.VolumeMounts)
+
+	assertions.Equal([]v1.Volume{{Name: "tmp", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}, deployment.Spec.Template.Spec.Volumes)
+}
+
+func TestDeploymentBaselineSecurityPresetWithOverrides(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"securityPreset":                              "baseline",
+		"securityContext.runAsUser":                   "1000",
+		"securityContext.allowPrivilegeEscalation":    "true",
+		"initContainers[0].name":                      "chown",
+		"initContainers[0].command[0]":                "chown",
+		"initContainers[0].securityContext.runAsUser": "0",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal(&v1.PodSecurityContext{
+		SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+	}, deployment.Spec.Template.Spec.SecurityContext)
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assertions.True(*container.SecurityContext.AllowPrivilegeEscalation)
+	assertions.False(*container.SecurityContext.Privileged)
+	assertions.Equal(int64(1000), *container.SecurityContext.RunAsUser)
+	assertions.Nil(container.SecurityContext.ReadOnlyRootFilesystem)
+	assertions.Empty(container.VolumeMounts)
+	assertions.Empty(deployment.Spec.Template.Spec.Volumes)
+
+	initContainer := deployment.Spec.Template.Spec.InitContainers[0]
+	assertions.Equal(int64(0), *initContainer.SecurityContext.RunAsUser)
+	assertions.True(*initContainer.SecurityContext.AllowPrivilegeEscalation)
+}
+
+func TestDeploymentInvalidSecurityPreset(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"securityPreset": "privileged",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid securityPreset, must be one of (restricted,baseline,none)")
+}
+
 func TestExtraEnvVarList(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -576,6 +968,101 @@ func TestExtraEnvVarList(t *testing.T) {
 
 }
 
+func TestDeploymentWithEnvSources(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+		ValuesFiles:    []string{"values-env-sources.yaml"},
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/deployment.yaml"})
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+
+	podName, err := findByKey("POD_NAME", container.Env)
+	assertions.NoError(err)
+	assertions.Equal(&v1.ObjectFieldSelector{FieldPath: "metadata.name"}, podName.ValueFrom.FieldRef)
+
+	cpuLimit, err := findByKey("CPU_LIMIT", container.Env)
+	assertions.NoError(err)
+	assertions.Equal(&v1.ResourceFieldSelector{Resource: "limits.cpu"}, cpuLimit.ValueFrom.ResourceFieldRef)
+
+	memoryLimit, err := findByKey("MEMORY_LIMIT", container.Env)
+	assertions.NoError(err)
+	assertions.Equal("limits.memory", memoryLimit.ValueFrom.ResourceFieldRef.Resource)
+	assertions.Equal("1Mi", memoryLimit.ValueFrom.ResourceFieldRef.Divisor.String())
+
+	dbPassword, err := findByKey("DB_PASSWORD", container.Env)
+	assertions.NoError(err)
+	assertions.Equal(releaseName+"-postgresql", dbPassword.ValueFrom.SecretKeyRef.Name)
+	assertions.Equal("password", dbPassword.ValueFrom.SecretKeyRef.Key)
+	assertions.Nil(dbPassword.ValueFrom.SecretKeyRef.Optional)
+
+	featureFlags, err := findByKey("FEATURE_FLAGS", container.Env)
+	assertions.NoError(err)
+	assertions.Equal("shared-features", featureFlags.ValueFrom.ConfigMapKeyRef.Name)
+	assertions.Equal("FEATURE_FLAGS", featureFlags.ValueFrom.ConfigMapKeyRef.Key)
+	assertions.True(*featureFlags.ValueFrom.ConfigMapKeyRef.Optional)
+
+	expectedEnvFrom := []v1.EnvFromSource{
+		{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: releaseName + "-credentials"}}},
+		{Prefix: "SHARED_", ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "shared-settings"}}},
+	}
+	assertions.Equal(expectedEnvFrom, container.EnvFrom)
+
+	initContainers := deployment.Spec.Template.Spec.InitContainers
+	assertions.Len(initContainers, 2)
+	assertions.Equal(expectedEnvFrom, initContainers[0].EnvFrom)
+	_, err = findByKey("POD_IP", initContainers[0].Env)
+	assertions.NoError(err)
+	assertions.Empty(initContainers[1].EnvFrom)
+}
+
+func TestDeploymentWithInvalidEnvSources(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	tests := map[string]struct {
+		values   map[string]string
+		errorMsg string
+	}{
+		"secretKeyRef without name": {
+			values:   map[string]string{"env.secretKeyRef.DB_PASSWORD.key": "password"},
+			errorMsg: "Invalid env.secretKeyRef.DB_PASSWORD, name must be set",
+		},
+		"configMapKeyRef as string": {
+			values:   map[string]string{"env.configMapKeyRef.FEATURE_FLAGS": "shared-features"},
+			errorMsg: "Invalid env.configMapKeyRef.FEATURE_FLAGS, name must be set",
+		},
+		"envFrom without source": {
+			values:   map[string]string{"envFrom[0].prefix": "SHARED_"},
+			errorMsg: "Invalid envFrom, secretRef or configMapRef must be set",
+		},
+	}
+
+	for name, test := range tests {
+		options := &helm.Options{
+			SetValues:      test.values,
+			KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+		}
+
+		_, err := helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+		assertions.Error(err, name)
+		assertions.Contains(err.Error(), test.errorMsg, name)
+	}
+}
+
 func TestContainerPreStopHook(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -600,6 +1087,50 @@ func TestContainerPreStopHook(t *testing.T) {
 
 }
 
+func TestDeploymentNamedPorts(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ports[0].name":          "grpc",
+		"ports[0].containerPort": "9090",
+		"ports[1].name":          "admin",
+		"ports[1].containerPort": "9901",
+		"ports[1].expose":        "false",
+		"ports[2].name":          "dns",
+		"ports[2].containerPort": "53",
+		"ports[2].protocol":      "UDP",
+	}
+	deployment, _, _ := givenADeploymentTemplateWithHelm(t, assertions, values)
+
+	ports := deployment.Spec.Template.Spec.Containers[0].Ports
+	assertions.Equal([]v1.ContainerPort{
+		{Name: "grpc", ContainerPort: 9090, Protocol: "TCP"},
+		{Name: "admin", ContainerPort: 9901, Protocol: "TCP"},
+		{Name: "dns", ContainerPort: 53, Protocol: "UDP"},
+	}, ports)
+}
+
+func TestDeploymentInvalidPorts(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"ports[0].name": "grpc",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid ports, name and containerPort must be set")
+}
+
 func TestDeploymentRecreateStrategy(t *testing.T) {
 	t.Parallel()
 	assertions := require.New(t)
@@ -644,8 +1175,6 @@ func TestDeploymentInvalidStrategy(t *testing.T) {
 
 	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
 
-	errors.New("Invalid strategy type, must be one of (RollingUpdate,Recreate)")
-
 	assertions.Error(err)
 	assertions.Contains(err.Error(), "Invalid strategy type, must be one of (RollingUpdate,Recreate)")
 }
@@ -1,7 +1,6 @@
 package deployment
 
 import (
-	"errors"
 	"github.com/gruntwork-io/terratest/modules/helm"
 	"github.com/gruntwork-io/terratest/modules/k8s"
 	"github.com/gruntwork-io/terratest/modules/random"
@@ -206,8 +205,6 @@ func TestDeploymentInvalidProbeType(t *testing.T) {
 
 	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
 
-	errors.New("Invalid probe type, must be one of (httpGet,tcpSocket,exec)")
-
 	assertions.Error(err)
 	assertions.Contains(err.Error(), "Invalid probe type, must be one of (httpGet,tcpSocket,exec)")
 }
@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"strings"
+	"testing"
+)
+
+func givenAGRPCRouteTemplateWithHelm(t *testing.T, require *require.Assertions, options *helm.Options) (string, gatewayv1.GRPCRoute) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	options.KubectlOptions = k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId()))
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/grpcroute.yaml"})
+
+	var route gatewayv1.GRPCRoute
+	helm.UnmarshalK8SYaml(t, output, &route)
+	return releaseName, route
+}
+
+func TestGRPCRouteDefaults(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"grpcRoute.enabled":            "true",
+			"httpRoute.parentRefs[0].name": "public",
+			"ingress.hosts[0]":             "grpc.example.com",
+		},
+	}
+	releaseName, route := givenAGRPCRouteTemplateWithHelm(t, require, options)
+
+	require.Equal("gateway.networking.k8s.io/v1", route.APIVersion)
+	require.Equal("GRPCRoute", route.Kind)
+	require.Equal(releaseName+"-chart-test", route.Name)
+	require.Equal([]gatewayv1.ParentReference{{Name: "public"}}, route.Spec.ParentRefs)
+	require.Equal([]gatewayv1.Hostname{"grpc.example.com"}, route.Spec.Hostnames)
+	require.Len(route.Spec.Rules, 1)
+	require.Empty(route.Spec.Rules[0].Matches)
+	require.Empty(route.Spec.Rules[0].Filters)
+
+	port := gatewayv1.PortNumber(8000)
+	require.Equal([]gatewayv1.GRPCBackendRef{{BackendRef: gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(releaseName + "-chart-test"), Port: &port},
+	}}}, route.Spec.Rules[0].BackendRefs)
+}
+
+func TestGRPCRouteRules(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	options := &helm.Options{
+		ValuesFiles: []string{"values-route-rules.yaml"},
+		SetValues: map[string]string{
+			"grpcRoute.parentRefs[0].name":       "internal",
+			"grpcRoute.hostnames[0]":             "grpc.example.com",
+			"grpcRoute.requestHeaders.remove[0]": "X-Debug",
+		},
+	}
+	releaseName, route := givenAGRPCRouteTemplateWithHelm(t, require, options)
+
+	require.Equal([]gatewayv1.ParentReference{{Name: "internal"}}, route.Spec.ParentRefs)
+	require.Equal([]gatewayv1.Hostname{"grpc.example.com"}, route.Spec.Hostnames)
+	require.Len(route.Spec.Rules, 1)
+	require.Equal([]gatewayv1.GRPCRouteMatch{{
+		Method:  &gatewayv1.GRPCMethodMatch{Service: stringPointer("helloworld.Greeter"), Method: stringPointer("SayHello")},
+		Headers: []gatewayv1.GRPCHeaderMatch{{Name: "X-Tenant", Value: "acme"}},
+	}}, route.Spec.Rules[0].Matches)
+
+	port := gatewayv1.PortNumber(9090)
+	require.Equal([]gatewayv1.GRPCBackendRef{{BackendRef: gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(releaseName + "-chart-test"), Port: &port},
+		Weight:                 int32Pointer(1),
+	}}}, route.Spec.Rules[0].BackendRefs)
+	require.Equal([]gatewayv1.GRPCRouteFilter{{
+		Type:                  gatewayv1.GRPCRouteFilterRequestHeaderModifier,
+		RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{Remove: []string{"X-Debug"}},
+	}}, route.Spec.Rules[0].Filters)
+}
+
+func TestGRPCRouteWithoutParentRefs(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"grpcRoute.enabled": "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/grpcroute.yaml"})
+	require.Error(err)
+	require.Contains(err.Error(), "Invalid grpcRoute, parentRefs must be set")
+}
@@ -0,0 +1,232 @@
+package gateway
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"strings"
+	"testing"
+)
+
+func givenAnHTTPRouteTemplateWithHelm(t *testing.T, require *require.Assertions, valuesFile string, values map[string]string) (string, string, []gatewayv1.HTTPRoute) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		ValuesFiles:    []string{valuesFile},
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/httproute.yaml"})
+
+	var routes []gatewayv1.HTTPRoute
+	for _, document := range strings.Split(output, "\n---\n") {
+		var route gatewayv1.HTTPRoute
+		helm.UnmarshalK8SYaml(t, document, &route)
+		routes = append(routes, route)
+	}
+	return namespaceName, releaseName, routes
+}
+
+func TestHTTPRouteDerivedFromIngress(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	namespaceName, releaseName, routes := givenAnHTTPRouteTemplateWithHelm(t, require, "values-httproute.yaml", map[string]string{})
+	require.Len(routes, 2)
+
+	gatewayNamespace := gatewayv1.Namespace("gateway-system")
+	sectionName := gatewayv1.SectionName(namespaceName + "-https")
+	parentRefs := []gatewayv1.ParentReference{{Name: "public", Namespace: &gatewayNamespace, SectionName: &sectionName}}
+	pathPrefix := gatewayv1.PathMatchPathPrefix
+	exact := gatewayv1.PathMatchExact
+	filters := []gatewayv1.HTTPRouteFilter{{
+		Type: gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+		RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{
+			Set:    []gatewayv1.HTTPHeader{{Name: "X-Env", Value: "prod"}},
+			Remove: []string{"X-Debug"},
+		},
+	}}
+
+	route := routes[0]
+	require.Equal("gateway.networking.k8s.io/v1", route.APIVersion)
+	require.Equal("HTTPRoute", route.Kind)
+	require.Equal(releaseName+"-chart-test", route.Name)
+	require.Equal(parentRefs, route.Spec.ParentRefs)
+	require.Equal([]gatewayv1.Hostname{gatewayv1.Hostname(releaseName + ".example.com")}, route.Spec.Hostnames)
+	require.Len(route.Spec.Rules, 2)
+	require.Equal([]gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{Type: &pathPrefix, Value: stringPointer("/")}}}, route.Spec.Rules[0].Matches)
+	require.Equal(backendRef(releaseName+"-chart-test", 8000), route.Spec.Rules[0].BackendRefs[0])
+	require.Equal(filters, route.Spec.Rules[0].Filters)
+	require.Equal([]gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{Type: &pathPrefix, Value: stringPointer("/static")}}}, route.Spec.Rules[1].Matches)
+	require.Equal(backendRef("static", 8080), route.Spec.Rules[1].BackendRefs[0])
+
+	route = routes[1]
+	require.Equal(releaseName+"-chart-test-api-"+releaseName+"-example-com", route.Name)
+	require.Equal(parentRefs, route.Spec.ParentRefs)
+	require.Equal([]gatewayv1.Hostname{gatewayv1.Hostname("api." + releaseName + ".example.com")}, route.Spec.Hostnames)
+	require.Len(route.Spec.Rules, 1)
+	require.Equal([]gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{Type: &exact, Value: stringPointer("/v1")}}}, route.Spec.Rules[0].Matches)
+	require.Equal([]gatewayv1.HTTPBackendRef{backendRef(releaseName+"-api", 8000)}, route.Spec.Rules[0].BackendRefs)
+	require.Equal(filters, route.Spec.Rules[0].Filters)
+}
+
+func TestHTTPRouteRules(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	_, releaseName, routes := givenAnHTTPRouteTemplateWithHelm(t, require, "values-route-rules.yaml", map[string]string{})
+	require.Len(routes, 1)
+
+	route := routes[0]
+	require.Equal(releaseName+"-chart-test", route.Name)
+	require.Equal([]gatewayv1.Hostname{gatewayv1.Hostname(releaseName + ".example.com")}, route.Spec.Hostnames)
+	require.Len(route.Spec.Rules, 2)
+
+	pathPrefix := gatewayv1.PathMatchPathPrefix
+	require.Equal([]gatewayv1.HTTPRouteMatch{{
+		Path:    &gatewayv1.HTTPPathMatch{Type: &pathPrefix, Value: stringPointer("/api")},
+		Headers: []gatewayv1.HTTPHeaderMatch{{Name: "X-Canary", Value: "true"}},
+	}}, route.Spec.Rules[0].Matches)
+	canary := backendRef(releaseName+"-canary", 8000)
+	canary.Weight = int32Pointer(10)
+	stable := backendRef(releaseName+"-chart-test", 8000)
+	stable.Weight = int32Pointer(90)
+	require.Equal([]gatewayv1.HTTPBackendRef{canary, stable}, route.Spec.Rules[0].BackendRefs)
+
+	responseHeaders := gatewayv1.HTTPRouteFilter{
+		Type:                   gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+		ResponseHeaderModifier: &gatewayv1.HTTPHeaderFilter{Add: []gatewayv1.HTTPHeader{{Name: "X-Served-By", Value: releaseName}}},
+	}
+	require.Equal([]gatewayv1.HTTPRouteFilter{
+		{
+			Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{Set: []gatewayv1.HTTPHeader{{Name: "X-Canary", Value: "true"}}},
+		},
+		responseHeaders,
+	}, route.Spec.Rules[0].Filters)
+
+	require.Empty(route.Spec.Rules[1].Matches)
+	require.Equal([]gatewayv1.HTTPBackendRef{backendRef("legacy", 80)}, route.Spec.Rules[1].BackendRefs)
+	require.Len(route.Spec.Rules[1].Filters, 2)
+	require.Equal(responseHeaders, route.Spec.Rules[1].Filters[0])
+	require.Equal(gatewayv1.HTTPRouteFilterURLRewrite, route.Spec.Rules[1].Filters[1].Type)
+	require.Equal("/", *route.Spec.Rules[1].Filters[1].URLRewrite.Path.ReplacePrefixMatch)
+}
+
+func TestHTTPRouteReplacesTheIngress(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{"values-httproute.yaml"},
+		SetValues: map[string]string{
+			"ingress.enabled": "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"})
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/ingress.yaml in chart")
+}
+
+func TestHTTPRouteReplacesTheIngressesMap(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{"values-httproute.yaml"},
+		SetValues: map[string]string{
+			"ingresses.public.hosts[0]":   "public.example.com",
+			"ingresses.internal.hosts[0]": "internal.example.com",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"})
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/ingress.yaml in chart")
+}
+
+func TestHTTPRouteInvalidValues(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	tests := map[string]struct {
+		values   map[string]string
+		errorMsg string
+	}{
+		"missing parentRefs": {
+			values:   map[string]string{},
+			errorMsg: "Invalid httpRoute, parentRefs must be set",
+		},
+		"unknown port name": {
+			values: map[string]string{
+				"httpRoute.parentRefs[0].name":         "public",
+				"ingress.paths[0].path":                "/",
+				"ingress.paths[0].backend.servicePort": "grpc",
+			},
+			errorMsg: "Invalid httpRoute backendRefs port grpc, must be a number or the name of a port",
+		},
+		"port name of another service": {
+			values: map[string]string{
+				"httpRoute.parentRefs[0].name":         "public",
+				"ingress.paths[0].path":                "/",
+				"ingress.paths[0].backend.serviceName": "other-svc",
+				"ingress.paths[0].backend.servicePort": "http",
+			},
+			errorMsg: "Invalid httpRoute backendRefs port http of the service other-svc, the port of another service must be a number",
+		},
+		"invalid pathType": {
+			values: map[string]string{
+				"httpRoute.parentRefs[0].name": "public",
+				"ingress.pathType":             "Regex",
+			},
+			errorMsg: "Invalid ingress pathType, must be one of (Exact,Prefix,ImplementationSpecific)",
+		},
+	}
+
+	for name, test := range tests {
+		test.values["httpRoute.enabled"] = "true"
+		options := &helm.Options{
+			SetValues:      test.values,
+			KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+		}
+
+		_, err := helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/httproute.yaml"})
+		require.Error(err, name)
+		require.Contains(err.Error(), test.errorMsg, name)
+	}
+}
+
+func backendRef(name string, port int32) gatewayv1.HTTPBackendRef {
+	portNumber := gatewayv1.PortNumber(port)
+	return gatewayv1.HTTPBackendRef{BackendRef: gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(name), Port: &portNumber},
+	}}
+}
+
+func stringPointer(value string) *string {
+	return &value
+}
+
+func int32Pointer(value int32) *int32 {
+	return &value
+}
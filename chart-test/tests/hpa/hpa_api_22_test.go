@@ -0,0 +1,73 @@
+package hpa
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/autoscaling/v2beta2"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAnHpaTemplateWithHelmApi22(t *testing.T, require *require.Assertions, values map[string]string) (string, v2beta2.HorizontalPodAutoscaler) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/hpa.yaml"}, "--kube-version=v1.22.0")
+
+	var hpa v2beta2.HorizontalPodAutoscaler
+	helm.UnmarshalK8SYaml(t, output, &hpa)
+	return releaseName, hpa
+}
+
+func TestHpaBasicApi22(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled": "true",
+	}
+	releaseName, hpa := givenAnHpaTemplateWithHelmApi22(t, assertions, values)
+
+	assertions.Equal("autoscaling/v2beta2", hpa.APIVersion)
+	assertions.Equal(releaseName+"-chart-test", hpa.Name)
+
+	assertions.Equal("Deployment", hpa.Spec.ScaleTargetRef.Kind)
+	assertions.Equal(releaseName+"-chart-test", hpa.Spec.ScaleTargetRef.Name)
+	assertions.Equal(int32(1), *hpa.Spec.MinReplicas)
+	assertions.Equal(int32(3), hpa.Spec.MaxReplicas)
+
+	assertions.Len(hpa.Spec.Metrics, 1)
+	assertions.Equal(v2beta2.ResourceMetricSourceType, hpa.Spec.Metrics[0].Type)
+	assertions.Equal("cpu", hpa.Spec.Metrics[0].Resource.Name.String())
+	assertions.Equal(int32(80), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+}
+
+func TestHpaBehaviorApi22(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled": "true",
+		"autoscaling.behavior.scaleDown.stabilizationWindowSeconds": "120",
+		"autoscaling.behavior.scaleDown.policies[0].type":           "Pods",
+		"autoscaling.behavior.scaleDown.policies[0].value":          "1",
+		"autoscaling.behavior.scaleDown.policies[0].periodSeconds":  "60",
+	}
+	_, hpa := givenAnHpaTemplateWithHelmApi22(t, assertions, values)
+
+	scaleDown := hpa.Spec.Behavior.ScaleDown
+	assertions.Equal(int32(120), *scaleDown.StabilizationWindowSeconds)
+	assertions.Equal(v2beta2.HPAScalingPolicy{Type: v2beta2.PodsScalingPolicy, Value: 1, PeriodSeconds: 60}, scaleDown.Policies[0])
+	assertions.Nil(hpa.Spec.Behavior.ScaleUp)
+}
@@ -0,0 +1,178 @@
+package hpa
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	v2 "k8s.io/api/autoscaling/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAnHpaTemplateWithHelmApi23(t *testing.T, require *require.Assertions, values map[string]string) (string, v2.HorizontalPodAutoscaler) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/hpa.yaml"}, "--kube-version=v1.23.0")
+
+	var hpa v2.HorizontalPodAutoscaler
+	helm.UnmarshalK8SYaml(t, output, &hpa)
+	return releaseName, hpa
+}
+
+func TestHpaBasicApi23(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled": "true",
+	}
+	releaseName, hpa := givenAnHpaTemplateWithHelmApi23(t, assertions, values)
+
+	assertions.Equal("autoscaling/v2", hpa.APIVersion)
+	assertions.Equal(releaseName+"-chart-test", hpa.Name)
+
+	assertions.Equal("apps/v1", hpa.Spec.ScaleTargetRef.APIVersion)
+	assertions.Equal("Deployment", hpa.Spec.ScaleTargetRef.Kind)
+	assertions.Equal(releaseName+"-chart-test", hpa.Spec.ScaleTargetRef.Name)
+	assertions.Equal(int32(1), *hpa.Spec.MinReplicas)
+	assertions.Equal(int32(3), hpa.Spec.MaxReplicas)
+
+	assertions.Len(hpa.Spec.Metrics, 1)
+	assertions.Equal(v2.ResourceMetricSourceType, hpa.Spec.Metrics[0].Type)
+	assertions.Equal("cpu", hpa.Spec.Metrics[0].Resource.Name.String())
+	assertions.Equal(v2.UtilizationMetricType, hpa.Spec.Metrics[0].Resource.Target.Type)
+	assertions.Equal(int32(80), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+
+	assertions.Nil(hpa.Spec.Behavior)
+}
+
+func TestHpaDisabledApi23(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/hpa.yaml"}, "--kube-version=v1.23.0")
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "could not find template templates/hpa.yaml in chart")
+}
+
+func TestHpaCpuAndMemoryApi23(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled":                           "true",
+		"autoscaling.minReplicas":                       "2",
+		"autoscaling.maxReplicas":                       "10",
+		"autoscaling.targetCPUUtilizationPercentage":    "60",
+		"autoscaling.targetMemoryUtilizationPercentage": "70",
+	}
+	_, hpa := givenAnHpaTemplateWithHelmApi23(t, assertions, values)
+
+	assertions.Equal(int32(2), *hpa.Spec.MinReplicas)
+	assertions.Equal(int32(10), hpa.Spec.MaxReplicas)
+
+	assertions.Len(hpa.Spec.Metrics, 2)
+	assertions.Equal("cpu", hpa.Spec.Metrics[0].Resource.Name.String())
+	assertions.Equal(int32(60), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	assertions.Equal("memory", hpa.Spec.Metrics[1].Resource.Name.String())
+	assertions.Equal(int32(70), *hpa.Spec.Metrics[1].Resource.Target.AverageUtilization)
+}
+
+func TestHpaCustomMetricsApi23(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled":                                               "true",
+		"autoscaling.targetCPUUtilizationPercentage":                        "null",
+		"autoscaling.metrics[0].type":                                       "Pods",
+		"autoscaling.metrics[0].pods.metric.name":                           "packets-per-second",
+		"autoscaling.metrics[0].pods.target.type":                           "AverageValue",
+		"autoscaling.metrics[0].pods.target.averageValue":                   "1k",
+		"autoscaling.metrics[1].type":                                       "External",
+		"autoscaling.metrics[1].external.metric.name":                       "queue_messages_ready",
+		"autoscaling.metrics[1].external.metric.selector.matchLabels.queue": "worker_tasks",
+		"autoscaling.metrics[1].external.target.type":                       "AverageValue",
+		"autoscaling.metrics[1].external.target.averageValue":               "30",
+	}
+	_, hpa := givenAnHpaTemplateWithHelmApi23(t, assertions, values)
+
+	assertions.Len(hpa.Spec.Metrics, 2)
+
+	assertions.Equal(v2.PodsMetricSourceType, hpa.Spec.Metrics[0].Type)
+	assertions.Equal("packets-per-second", hpa.Spec.Metrics[0].Pods.Metric.Name)
+	assertions.Equal(v2.AverageValueMetricType, hpa.Spec.Metrics[0].Pods.Target.Type)
+	assertions.Equal("1k", hpa.Spec.Metrics[0].Pods.Target.AverageValue.String())
+
+	assertions.Equal(v2.ExternalMetricSourceType, hpa.Spec.Metrics[1].Type)
+	assertions.Equal("queue_messages_ready", hpa.Spec.Metrics[1].External.Metric.Name)
+	assertions.Equal("worker_tasks", hpa.Spec.Metrics[1].External.Metric.Selector.MatchLabels["queue"])
+	assertions.Equal("30", hpa.Spec.Metrics[1].External.Target.AverageValue.String())
+}
+
+func TestHpaBehaviorApi23(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled": "true",
+		"autoscaling.behavior.scaleDown.stabilizationWindowSeconds": "300",
+		"autoscaling.behavior.scaleDown.policies[0].type":           "Percent",
+		"autoscaling.behavior.scaleDown.policies[0].value":          "10",
+		"autoscaling.behavior.scaleDown.policies[0].periodSeconds":  "60",
+		"autoscaling.behavior.scaleUp.selectPolicy":                 "Max",
+		"autoscaling.behavior.scaleUp.policies[0].type":             "Pods",
+		"autoscaling.behavior.scaleUp.policies[0].value":            "4",
+		"autoscaling.behavior.scaleUp.policies[0].periodSeconds":    "15",
+	}
+	_, hpa := givenAnHpaTemplateWithHelmApi23(t, assertions, values)
+
+	scaleDown := hpa.Spec.Behavior.ScaleDown
+	assertions.Equal(int32(300), *scaleDown.StabilizationWindowSeconds)
+	assertions.Len(scaleDown.Policies, 1)
+	assertions.Equal(v2.HPAScalingPolicy{Type: v2.PercentScalingPolicy, Value: 10, PeriodSeconds: 60}, scaleDown.Policies[0])
+
+	scaleUp := hpa.Spec.Behavior.ScaleUp
+	assertions.Equal(v2.MaxChangePolicySelect, *scaleUp.SelectPolicy)
+	assertions.Len(scaleUp.Policies, 1)
+	assertions.Equal(v2.HPAScalingPolicy{Type: v2.PodsScalingPolicy, Value: 4, PeriodSeconds: 15}, scaleUp.Policies[0])
+}
+
+func TestHpaInvalidTargetKindApi23(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"autoscaling.enabled":    "true",
+			"autoscaling.targetKind": "DaemonSet",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/hpa.yaml"}, "--kube-version=v1.23.0")
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid autoscaling targetKind, must be one of (Deployment,StatefulSet)")
+}
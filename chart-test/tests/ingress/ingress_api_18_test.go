@@ -6,6 +6,7 @@ import (
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/stretchr/testify/require"
 	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -101,3 +102,61 @@ func TestIngressCustomIngressClassApi18(t *testing.T) {
 	assertions.Equal("custom-ingress-class", ingress.Annotations["kubernetes.io/ingress.class"])
 
 }
+
+func TestIngressPerHostPathsApi18(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		ValuesFiles:    []string{"values-per-host-paths.yaml"},
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"}, "--kube-version=v1.18.0")
+	var ingress v1beta1.Ingress
+	helm.UnmarshalK8SYaml(t, output, &ingress)
+
+	assertions.Equal(&v1beta1.IngressBackend{ServiceName: "fallback", ServicePort: intstr.FromInt(80)}, ingress.Spec.Backend)
+	assertions.Len(ingress.Spec.Rules, 2)
+
+	a := ingress.Spec.Rules[0]
+	assertions.Equal("a.example.com", a.Host)
+	assertions.Len(a.HTTP.Paths, 1)
+	assertions.Equal("/", a.HTTP.Paths[0].Path)
+	assertions.Equal(v1beta1.PathType("Prefix"), *a.HTTP.Paths[0].PathType)
+	assertions.Equal(v1beta1.IngressBackend{ServiceName: namespaceName + "-service-name", ServicePort: intstr.FromInt(8000)}, a.HTTP.Paths[0].Backend)
+
+	b := ingress.Spec.Rules[1]
+	assertions.Equal("b.example.com", b.Host)
+	assertions.Len(b.HTTP.Paths, 1)
+	assertions.Equal("/api", b.HTTP.Paths[0].Path)
+	assertions.Equal(v1beta1.PathType("Exact"), *b.HTTP.Paths[0].PathType)
+	assertions.Equal(v1beta1.IngressBackend{ServiceName: "api", ServicePort: intstr.FromString("http")}, b.HTTP.Paths[0].Backend)
+
+	assertions.Equal([]string{"a.example.com", "b.example.com"}, ingress.Spec.TLS[0].Hosts)
+}
+
+func TestIngressPathTypeApi18(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":                      "true",
+		"ingress.pathType":                     "ImplementationSpecific",
+		"ingress.paths[0].path":                "/exact",
+		"ingress.paths[0].pathType":            "Exact",
+		"ingress.paths[0].backend.serviceName": "first-service",
+		"ingress.paths[1].path":                "/other",
+		"ingress.paths[1].backend.serviceName": "second-service",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi18(t, assertions, values)
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	assertions.Equal(v1beta1.PathType("Exact"), *paths[0].PathType)
+	assertions.Equal(v1beta1.PathType("ImplementationSpecific"), *paths[1].PathType)
+}
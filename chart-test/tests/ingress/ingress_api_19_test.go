@@ -96,3 +96,81 @@ func TestIngressCustomIngressClassApi19(t *testing.T) {
 	assertions.Equal("custom-ingress-class", *ingress.Spec.IngressClassName)
 
 }
+
+func TestIngressPerHostPathsApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		ValuesFiles:    []string{"values-per-host-paths.yaml"},
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"}, "--kube-version=v1.19.0")
+	var ingress v1.Ingress
+	helm.UnmarshalK8SYaml(t, output, &ingress)
+
+	assertions.Equal(&v1.IngressBackend{Service: &v1.IngressServiceBackend{Name: "fallback", Port: v1.ServiceBackendPort{Number: 80}}}, ingress.Spec.DefaultBackend)
+	assertions.Len(ingress.Spec.Rules, 2)
+
+	a := ingress.Spec.Rules[0]
+	assertions.Equal("a.example.com", a.Host)
+	assertions.Len(a.HTTP.Paths, 1)
+	assertions.Equal("/", a.HTTP.Paths[0].Path)
+	assertions.Equal(v1.PathType("Prefix"), *a.HTTP.Paths[0].PathType)
+	assertions.Equal(v1.IngressBackend{Service: &v1.IngressServiceBackend{Name: namespaceName + "-service-name", Port: v1.ServiceBackendPort{Number: 8000}}}, a.HTTP.Paths[0].Backend)
+
+	b := ingress.Spec.Rules[1]
+	assertions.Equal("b.example.com", b.Host)
+	assertions.Len(b.HTTP.Paths, 1)
+	assertions.Equal("/api", b.HTTP.Paths[0].Path)
+	assertions.Equal(v1.PathType("Exact"), *b.HTTP.Paths[0].PathType)
+	assertions.Equal(v1.IngressBackend{Service: &v1.IngressServiceBackend{Name: "api", Port: v1.ServiceBackendPort{Name: "http"}}}, b.HTTP.Paths[0].Backend)
+
+	assertions.Equal([]string{"a.example.com", "b.example.com"}, ingress.Spec.TLS[0].Hosts)
+}
+
+func TestIngressPathTypeApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":                      "true",
+		"ingress.pathType":                     "ImplementationSpecific",
+		"ingress.paths[0].path":                "/exact",
+		"ingress.paths[0].pathType":            "Exact",
+		"ingress.paths[0].backend.serviceName": "first-service",
+		"ingress.paths[1].path":                "/other",
+		"ingress.paths[1].backend.serviceName": "second-service",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi19(t, assertions, values)
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	assertions.Equal(v1.PathType("Exact"), *paths[0].PathType)
+	assertions.Equal(v1.PathType("ImplementationSpecific"), *paths[1].PathType)
+}
+
+func TestIngressInvalidPathType(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"ingress.enabled":  "true",
+			"ingress.pathType": "Regex",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"}, "--kube-version=v1.19.0")
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid ingress pathType, must be one of (Exact,Prefix,ImplementationSpecific)")
+}
@@ -0,0 +1,193 @@
+package ingress
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIngressTlsDisabledApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":     "true",
+		"ingress.tls.enabled": "false",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi19(t, assertions, values)
+
+	assertions.Empty(ingress.Spec.TLS)
+	assertions.NotEmpty(ingress.Spec.Rules)
+}
+
+func TestIngressTlsSecretNameApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":        "true",
+		"ingress.hosts[0]":       "a.example.com",
+		"ingress.hosts[1]":       "b.example.com",
+		"ingress.tls.secretName": "wildcard-tls",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi19(t, assertions, values)
+
+	assertions.Equal([]v1.IngressTLS{{Hosts: []string{"a.example.com", "b.example.com"}, SecretName: "wildcard-tls"}}, ingress.Spec.TLS)
+}
+
+func TestIngressTlsPerHostApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":     "true",
+		"ingress.hosts[0]":    "a.example.com",
+		"ingress.hosts[1]":    "*.b.example.com",
+		"ingress.tls.perHost": "true",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi19(t, assertions, values)
+
+	assertions.Equal([]v1.IngressTLS{
+		{Hosts: []string{"a.example.com"}, SecretName: "a-example-com-tls"},
+		{Hosts: []string{"*.b.example.com"}, SecretName: "wildcard-b-example-com-tls"},
+	}, ingress.Spec.TLS)
+}
+
+func TestIngressTlsEntriesApi18(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":                   "true",
+		"ingress.hosts[0]":                  "a.example.com",
+		"ingress.hosts[1]":                  "b.example.com",
+		"ingress.hosts[2]":                  "c.example.com",
+		"ingress.tls.entries[0].hosts[0]":   "a.example.com",
+		"ingress.tls.entries[0].hosts[1]":   "b.example.com",
+		"ingress.tls.entries[0].secretName": "ab-tls",
+		"ingress.tls.entries[1].hosts[0]":   "c.example.com",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi18(t, assertions, values)
+
+	assertions.Equal([]v1beta1.IngressTLS{
+		{Hosts: []string{"a.example.com", "b.example.com"}, SecretName: "ab-tls"},
+		{Hosts: []string{"c.example.com"}},
+	}, ingress.Spec.TLS)
+}
+
+func TestIngressTlsDisabledApi18(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":     "true",
+		"ingress.tls.enabled": "false",
+	}
+	_, _, ingress := givenAnIngressTemplateWithHelmApi18(t, assertions, values)
+
+	assertions.Empty(ingress.Spec.TLS)
+}
+
+func TestIngressCertManagerAnnotationsApi18(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":                   "true",
+		"ingress.hosts[0]":                  "a.example.com",
+		"ingress.certManager.clusterIssuer": "letsencrypt",
+	}
+	_, releaseName, ingress := givenAnIngressTemplateWithHelmApi18(t, assertions, values)
+
+	assertions.Equal("letsencrypt", ingress.Annotations["cert-manager.io/cluster-issuer"])
+	assertions.NotContains(ingress.Annotations, "cert-manager.io/issuer")
+	assertions.Equal([]v1beta1.IngressTLS{{Hosts: []string{"a.example.com"}, SecretName: releaseName + "-chart-test-tls"}}, ingress.Spec.TLS)
+}
+
+func TestIngressCertManagerIssuerAnnotationApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingress.enabled":            "true",
+		"ingress.certManager.issuer": "namespace-issuer",
+	}
+	_, releaseName, ingress := givenAnIngressTemplateWithHelmApi19(t, assertions, values)
+
+	assertions.Equal("namespace-issuer", ingress.Annotations["cert-manager.io/issuer"])
+	assertions.NotContains(ingress.Annotations, "cert-manager.io/cluster-issuer")
+	assertions.Equal(releaseName+"-chart-test-tls", ingress.Spec.TLS[0].SecretName)
+}
+
+func TestIngressCertManagerCertificateApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"ingress.enabled":                   "true",
+			"ingress.hosts[0]":                  "a.example.com",
+			"ingress.hosts[1]":                  "b.example.com",
+			"ingress.tls.perHost":               "true",
+			"ingress.certManager.clusterIssuer": "letsencrypt",
+			"ingress.certManager.certificate":   "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"}, "--kube-version=v1.19.0")
+	documents := strings.Split(output, "\n---\n")
+	assertions.Len(documents, 3)
+
+	var ingress v1.Ingress
+	helm.UnmarshalK8SYaml(t, documents[0], &ingress)
+	assertions.NotContains(ingress.Annotations, "cert-manager.io/cluster-issuer")
+	assertions.Len(ingress.Spec.TLS, 2)
+
+	for i, host := range []string{"a.example.com", "b.example.com"} {
+		var certificate unstructured.Unstructured
+		helm.UnmarshalK8SYaml(t, documents[i+1], &certificate)
+
+		secretName := strings.ReplaceAll(host, ".", "-") + "-tls"
+		assertions.Equal("cert-manager.io/v1", certificate.GetAPIVersion())
+		assertions.Equal("Certificate", certificate.GetKind())
+		assertions.Equal(secretName, certificate.GetName())
+		assertions.Equal(map[string]interface{}{
+			"secretName": secretName,
+			"dnsNames":   []interface{}{host},
+			"issuerRef":  map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer"},
+		}, certificate.Object["spec"])
+		assertions.Equal(secretName, ingress.Spec.TLS[i].SecretName)
+	}
+}
+
+func TestIngressCertManagerWithBothIssuers(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"ingress.enabled":                   "true",
+			"ingress.certManager.clusterIssuer": "letsencrypt",
+			"ingress.certManager.issuer":        "namespace-issuer",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/ingress.yaml"}, "--kube-version=v1.19.0")
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid ingress.certManager, set either clusterIssuer or issuer")
+}
@@ -0,0 +1,101 @@
+package ingress
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/api/networking/v1beta1"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenIngressesRenderedWithHelm(t *testing.T, require *require.Assertions, kubeVersion string, values map[string]string) (string, string, []string) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		ValuesFiles:    []string{"values-ingresses.yaml"},
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/ingress.yaml"}, "--kube-version="+kubeVersion)
+	return namespaceName, releaseName, strings.Split(output, "\n---\n")
+}
+
+func TestIngressesApi19(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	namespaceName, releaseName, documents := givenIngressesRenderedWithHelm(t, assertions, "v1.19.0", map[string]string{})
+	assertions.Len(documents, 2)
+
+	var internal, public v1.Ingress
+	helm.UnmarshalK8SYaml(t, documents[0], &internal)
+	helm.UnmarshalK8SYaml(t, documents[1], &public)
+
+	assertions.Equal(releaseName+"-chart-test-private", internal.Name)
+	assertions.Equal(namespaceName+"-internal", *internal.Spec.IngressClassName)
+	assertions.Equal(map[string]string{"nginx.ingress.kubernetes.io/proxy-body-size": "8m"}, internal.Annotations)
+	assertions.Empty(internal.Spec.TLS)
+	assertions.Len(internal.Spec.Rules, 1)
+	assertions.Equal(releaseName+".internal.example.com", internal.Spec.Rules[0].Host)
+	assertions.Equal("/admin", internal.Spec.Rules[0].HTTP.Paths[0].Path)
+	assertions.Equal(v1.IngressBackend{Service: &v1.IngressServiceBackend{Name: releaseName + "-chart-test", Port: v1.ServiceBackendPort{Name: "http"}}}, internal.Spec.Rules[0].HTTP.Paths[0].Backend)
+
+	assertions.Equal(releaseName+"-chart-test-public", public.Name)
+	assertions.Equal("public-nginx", *public.Spec.IngressClassName)
+	assertions.Equal("letsencrypt", public.Annotations["cert-manager.io/cluster-issuer"])
+	assertions.Equal("0.0.0.0/0", public.Annotations["nginx.ingress.kubernetes.io/whitelist-source-range"])
+	assertions.Equal("8m", public.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"])
+	assertions.Equal([]v1.IngressTLS{{Hosts: []string{releaseName + ".example.com"}, SecretName: releaseName + "-chart-test-public-tls"}}, public.Spec.TLS)
+	assertions.Len(public.Spec.Rules, 1)
+	assertions.Equal(releaseName+".example.com", public.Spec.Rules[0].Host)
+	assertions.Equal("/", public.Spec.Rules[0].HTTP.Paths[0].Path)
+	assertions.Equal(namespaceName+"-service-name", public.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+}
+
+func TestIngressesApi18(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	namespaceName, releaseName, documents := givenIngressesRenderedWithHelm(t, assertions, "v1.18.0", map[string]string{})
+	assertions.Len(documents, 2)
+
+	var internal, public v1beta1.Ingress
+	helm.UnmarshalK8SYaml(t, documents[0], &internal)
+	helm.UnmarshalK8SYaml(t, documents[1], &public)
+
+	assertions.Equal(releaseName+"-chart-test-private", internal.Name)
+	assertions.Equal(namespaceName+"-internal", internal.Annotations["kubernetes.io/ingress.class"])
+	assertions.Equal(releaseName+".internal.example.com", internal.Spec.Rules[0].Host)
+
+	assertions.Equal(releaseName+"-chart-test-public", public.Name)
+	assertions.Equal("public-nginx", public.Annotations["kubernetes.io/ingress.class"])
+	assertions.Equal(releaseName+".example.com", public.Spec.Rules[0].Host)
+}
+
+func TestIngressesOverrideNestedField(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"ingresses.public.tls.secretName": "public-tls",
+	}
+	_, releaseName, documents := givenIngressesRenderedWithHelm(t, assertions, "v1.19.0", values)
+	assertions.Len(documents, 2)
+
+	var internal, public v1.Ingress
+	helm.UnmarshalK8SYaml(t, documents[0], &internal)
+	helm.UnmarshalK8SYaml(t, documents[1], &public)
+
+	assertions.Empty(internal.Spec.TLS)
+	assertions.Equal([]v1.IngressTLS{{Hosts: []string{releaseName + ".example.com"}, SecretName: "public-tls"}}, public.Spec.TLS)
+	assertions.Equal("letsencrypt", public.Annotations["cert-manager.io/cluster-issuer"])
+}
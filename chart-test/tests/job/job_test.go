@@ -0,0 +1,184 @@
+package job
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAJobTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, batchV1.Job) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/job.yaml"})
+
+	var job batchV1.Job
+	helm.UnmarshalK8SYaml(t, output, &job)
+	return releaseName, job
+}
+
+func TestJobBasic(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	releaseName, job := givenAJobTemplateWithHelm(t, assertions, map[string]string{})
+
+	assertions.Equal(releaseName+"-chart-test-job", job.Name)
+	assertions.Empty(job.Annotations)
+	assertions.Equal("chart-test", job.Labels["app.kubernetes.io/name"])
+
+	assertions.Nil(job.Spec.ActiveDeadlineSeconds)
+	assertions.Equal(int32(6), *job.Spec.BackoffLimit)
+	assertions.Equal(int32(1), *job.Spec.Completions)
+	assertions.Equal(int32(1), *job.Spec.Parallelism)
+	assertions.Nil(job.Spec.TTLSecondsAfterFinished)
+	assertions.Equal(v1.RestartPolicyOnFailure, job.Spec.Template.Spec.RestartPolicy)
+
+	assertions.Equal("default", job.Spec.Template.Spec.ServiceAccountName)
+	assertions.Empty(job.Spec.Template.Spec.InitContainers)
+
+	containers := job.Spec.Template.Spec.Containers
+	assertions.Equal(1, len(containers))
+	container := containers[0]
+	assertions.Equal("nginx:latest", container.Image)
+	assertions.Equal("chart-test", container.Name)
+	assertions.Nil(container.Command)
+	assertions.Nil(container.Args)
+
+	envVars := container.Env
+	assertions.Equal(3, len(envVars))
+	assertions.Contains(envVars, v1.EnvVar{Name: "LOG_LEVEL_APP", Value: "INFO"})
+
+	assertions.Empty(container.Ports)
+	assertions.Nil(container.LivenessProbe)
+	assertions.Nil(container.ReadinessProbe)
+}
+
+func TestJobCustomValues(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"job.nameSuffix":                      "migration",
+		"cronJob.job.activeDeadlineSeconds":   "120",
+		"cronJob.job.backoffLimit":            "0",
+		"cronJob.job.ttlSecondsAfterFinished": "300",
+		"cronJob.job.podRestartPolicy":        "Never",
+	}
+	releaseName, job := givenAJobTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal(releaseName+"-chart-test-migration", job.Name)
+	assertions.Equal(int64(120), *job.Spec.ActiveDeadlineSeconds)
+	assertions.Equal(int32(0), *job.Spec.BackoffLimit)
+	assertions.Equal(int32(300), *job.Spec.TTLSecondsAfterFinished)
+	assertions.Equal(v1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+}
+
+func TestJobHelmHook(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"job.hook.events":       "pre-install\\,pre-upgrade",
+		"job.hook.weight":       "-5",
+		"job.hook.deletePolicy": "before-hook-creation\\,hook-succeeded",
+	}
+	_, job := givenAJobTemplateWithHelm(t, assertions, values)
+
+	annotations := map[string]string{
+		"helm.sh/hook":               "pre-install,pre-upgrade",
+		"helm.sh/hook-weight":        "-5",
+		"helm.sh/hook-delete-policy": "before-hook-creation,hook-succeeded",
+	}
+	assertions.Equal(annotations, job.Annotations)
+}
+
+func TestJobCommandOverride(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"application.command[0]": "/app/server",
+		"application.args[0]":    "--port=8000",
+		"job.command[0]":         "/app/migrate",
+		"job.args[0]":            "up",
+	}
+	_, job := givenAJobTemplateWithHelm(t, assertions, values)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assertions.Equal([]string{"/app/migrate"}, container.Command)
+	assertions.Equal([]string{"up"}, container.Args)
+}
+
+func TestJobCommandOverrideWithoutArgs(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"application.args[0]": "serve",
+		"job.command[0]":      "/migrate",
+	}
+	_, job := givenAJobTemplateWithHelm(t, assertions, values)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assertions.Equal([]string{"/migrate"}, container.Command)
+	assertions.Nil(container.Args)
+}
+
+func TestJobCommandFallback(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"application.command[0]": "/app/server",
+		"application.args[0]":    "--port=8000",
+		"job.args[0]":            "migrate",
+	}
+	_, job := givenAJobTemplateWithHelm(t, assertions, values)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assertions.Equal([]string{"/app/server"}, container.Command)
+	assertions.Equal([]string{"migrate"}, container.Args)
+}
+
+func TestJobPodsNotSelectedByTheService(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"podLabels.sidecar-injection": "enabled",
+	}
+	_, job := givenAJobTemplateWithHelm(t, assertions, values)
+
+	podLabels := job.Spec.Template.Labels
+	assertions.Equal("job", podLabels["app.kubernetes.io/component"])
+	assertions.Equal("enabled", podLabels["sidecar-injection"])
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/service.yaml"})
+	var service v1.Service
+	helm.UnmarshalK8SYaml(t, output, &service)
+
+	assertions.NotEmpty(service.Spec.Selector)
+	assertions.False(labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(podLabels)))
+}
@@ -0,0 +1,84 @@
+package monitoring
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAPodMonitorTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, unstructured.Unstructured) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/podmonitor.yaml"}, monitoringApiVersion)
+
+	var podMonitor unstructured.Unstructured
+	helm.UnmarshalK8SYaml(t, output, &podMonitor)
+	return releaseName, podMonitor
+}
+
+func TestPodMonitorBasic(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.podMonitor.enabled": "true",
+	}
+	releaseName, podMonitor := givenAPodMonitorTemplateWithHelm(t, require, values)
+
+	require.Equal("PodMonitor", podMonitor.GetKind())
+	require.Equal(releaseName+"-chart-test", podMonitor.GetName())
+
+	matchLabels, _, err := unstructured.NestedStringMap(podMonitor.Object, "spec", "selector", "matchLabels")
+	require.NoError(err)
+	require.Equal(map[string]string{"app.kubernetes.io/name": "chart-test", "app.kubernetes.io/instance": releaseName}, matchLabels)
+
+	endpoints, _, err := unstructured.NestedSlice(podMonitor.Object, "spec", "podMetricsEndpoints")
+	require.NoError(err)
+	require.Len(endpoints, 1)
+	require.Equal(map[string]interface{}{"port": "health-check", "path": "/metrics"}, endpoints[0])
+}
+
+func TestPodMonitorServerPort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.podMonitor.enabled": "true",
+		"metrics.port":               "8000",
+		"metrics.interval":           "15s",
+	}
+	_, podMonitor := givenAPodMonitorTemplateWithHelm(t, require, values)
+
+	endpoints, _, err := unstructured.NestedSlice(podMonitor.Object, "spec", "podMetricsEndpoints")
+	require.NoError(err)
+	require.Equal(map[string]interface{}{"port": "http", "path": "/metrics", "interval": "15s"}, endpoints[0])
+}
+
+func TestPodMonitorCustomPort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.podMonitor.enabled": "true",
+		"metrics.port":               "9100",
+	}
+	_, podMonitor := givenAPodMonitorTemplateWithHelm(t, require, values)
+
+	endpoints, _, err := unstructured.NestedSlice(podMonitor.Object, "spec", "podMetricsEndpoints")
+	require.NoError(err)
+	require.Equal(int64(9100), endpoints[0].(map[string]interface{})["targetPort"])
+}
@@ -0,0 +1,183 @@
+package monitoring
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const monitoringApiVersion = "--api-versions=monitoring.coreos.com/v1"
+
+func givenAServiceMonitorTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, string, unstructured.Unstructured) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/servicemonitor.yaml"}, monitoringApiVersion)
+
+	var serviceMonitor unstructured.Unstructured
+	helm.UnmarshalK8SYaml(t, output, &serviceMonitor)
+	return namespaceName, releaseName, serviceMonitor
+}
+
+func TestServiceMonitorBasic(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.serviceMonitor.enabled": "true",
+	}
+	namespaceName, releaseName, serviceMonitor := givenAServiceMonitorTemplateWithHelm(t, require, values)
+
+	require.Equal("monitoring.coreos.com/v1", serviceMonitor.GetAPIVersion())
+	require.Equal("ServiceMonitor", serviceMonitor.GetKind())
+	require.Equal(releaseName+"-chart-test", serviceMonitor.GetName())
+
+	matchLabels, _, err := unstructured.NestedStringMap(serviceMonitor.Object, "spec", "selector", "matchLabels")
+	require.NoError(err)
+	require.Equal(map[string]string{"app.kubernetes.io/name": "chart-test", "app.kubernetes.io/instance": releaseName}, matchLabels)
+
+	namespaces, _, err := unstructured.NestedStringSlice(serviceMonitor.Object, "spec", "namespaceSelector", "matchNames")
+	require.NoError(err)
+	require.Equal([]string{namespaceName}, namespaces)
+
+	endpoints, _, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	require.NoError(err)
+	require.Len(endpoints, 1)
+	require.Equal(map[string]interface{}{"port": "metrics", "path": "/metrics"}, endpoints[0])
+}
+
+func TestServiceMonitorScrapeSettings(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.serviceMonitor.enabled":               "true",
+		"metrics.path":                                 "/actuator/prometheus",
+		"metrics.interval":                             "30s",
+		"metrics.scrapeTimeout":                        "10s",
+		"metrics.scheme":                               "https",
+		"metrics.tlsConfig.insecureSkipVerify":         "true",
+		"metrics.labels.release":                       "prometheus",
+		"metrics.relabelings[0].sourceLabels[0]":       "__meta_kubernetes_pod_node_name",
+		"metrics.relabelings[0].targetLabel":           "node",
+		"metrics.metricRelabelings[0].action":          "drop",
+		"metrics.metricRelabelings[0].sourceLabels[0]": "__name__",
+		"metrics.metricRelabelings[0].regex":           "jvm_.*",
+	}
+	_, _, serviceMonitor := givenAServiceMonitorTemplateWithHelm(t, require, values)
+
+	require.Equal("prometheus", serviceMonitor.GetLabels()["release"])
+	require.Equal("chart-test", serviceMonitor.GetLabels()["app.kubernetes.io/name"])
+
+	endpoints, _, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	require.NoError(err)
+	endpoint := endpoints[0].(map[string]interface{})
+	require.Equal("metrics", endpoint["port"])
+	require.Equal("/actuator/prometheus", endpoint["path"])
+	require.Equal("30s", endpoint["interval"])
+	require.Equal("10s", endpoint["scrapeTimeout"])
+	require.Equal("https", endpoint["scheme"])
+	require.Equal(map[string]interface{}{"insecureSkipVerify": true}, endpoint["tlsConfig"])
+
+	relabelings := endpoint["relabelings"].([]interface{})
+	require.Len(relabelings, 1)
+	require.Equal("node", relabelings[0].(map[string]interface{})["targetLabel"])
+
+	metricRelabelings := endpoint["metricRelabelings"].([]interface{})
+	require.Len(metricRelabelings, 1)
+	require.Equal("jvm_.*", metricRelabelings[0].(map[string]interface{})["regex"])
+}
+
+func TestServiceMonitorExistingServicePort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.serviceMonitor.enabled": "true",
+		"ports[0].name":                  "http",
+		"ports[0].containerPort":         "8000",
+		"ports[1].name":                  "admin",
+		"ports[1].containerPort":         "9901",
+		"metrics.port":                   "9901",
+	}
+	_, _, serviceMonitor := givenAServiceMonitorTemplateWithHelm(t, require, values)
+
+	endpoints, _, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	require.NoError(err)
+	require.Equal("admin", endpoints[0].(map[string]interface{})["port"])
+}
+
+func TestServiceMonitorExistingContainerPort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.serviceMonitor.enabled": "true",
+		"ports[0].name":                  "http",
+		"ports[0].containerPort":         "8080",
+		"ports[0].servicePort":           "9000",
+		"ports[1].name":                  "admin",
+		"ports[1].containerPort":         "9000",
+		"ports[1].servicePort":           "9901",
+		"metrics.port":                   "9000",
+	}
+	_, _, serviceMonitor := givenAServiceMonitorTemplateWithHelm(t, require, values)
+
+	endpoints, _, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	require.NoError(err)
+	require.Equal("admin", endpoints[0].(map[string]interface{})["port"])
+}
+
+func TestServiceMonitorWithoutOperatorApi(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"metrics.serviceMonitor.enabled": "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/servicemonitor.yaml"})
+
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/servicemonitor.yaml in chart")
+}
+
+func TestServiceMonitorMetricsDisabled(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"metrics.enabled":                "false",
+			"metrics.serviceMonitor.enabled": "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/servicemonitor.yaml"}, monitoringApiVersion)
+
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/servicemonitor.yaml in chart")
+}
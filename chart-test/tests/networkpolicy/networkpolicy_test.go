@@ -0,0 +1,238 @@
+package networkpolicy
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenANetworkPolicyTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, string, networkingv1.NetworkPolicy) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/networkpolicy.yaml"})
+
+	var networkPolicy networkingv1.NetworkPolicy
+	helm.UnmarshalK8SYaml(t, output, &networkPolicy)
+	return namespaceName, releaseName, networkPolicy
+}
+
+func TestNetworkPolicyBasic(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled": "true",
+	}
+	_, releaseName, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Equal(releaseName+"-chart-test", networkPolicy.Name)
+	require.Equal(map[string]string{"app.kubernetes.io/name": "chart-test", "app.kubernetes.io/instance": releaseName}, networkPolicy.Spec.PodSelector.MatchLabels)
+	require.Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, networkPolicy.Spec.PolicyTypes)
+	require.Empty(networkPolicy.Spec.Egress)
+
+	require.Len(networkPolicy.Spec.Ingress, 1)
+	rule := networkPolicy.Spec.Ingress[0]
+	require.Len(rule.From, 1)
+	require.Equal(map[string]string{"kubernetes.io/metadata.name": "monitoring"}, rule.From[0].NamespaceSelector.MatchLabels)
+	require.Nil(rule.From[0].PodSelector)
+	require.Len(rule.Ports, 1)
+	require.Equal(int32(9000), rule.Ports[0].Port.IntVal)
+	require.Equal(v1.ProtocolTCP, *rule.Ports[0].Protocol)
+}
+
+func TestNetworkPolicyIngressController(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled":                        "true",
+		"networkPolicy.ingressControllerPodLabels.app": "ingress-nginx",
+		"ingress.enabled":                              "true",
+		"metrics.enabled":                              "false",
+		"application.serverPort":                       "8080",
+	}
+	namespaceName, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Len(networkPolicy.Spec.Ingress, 1)
+	rule := networkPolicy.Spec.Ingress[0]
+	require.Equal(map[string]string{"kubernetes.io/metadata.name": namespaceName}, rule.From[0].NamespaceSelector.MatchLabels)
+	require.Equal(map[string]string{"app": "ingress-nginx"}, rule.From[0].PodSelector.MatchLabels)
+	require.Equal(int32(8080), rule.Ports[0].Port.IntVal)
+}
+
+func TestNetworkPolicyIngressesEntry(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled":      "true",
+		"metrics.enabled":            "false",
+		"ingresses.public.hosts[0]":  "a.example.com",
+		"ingresses.internal.enabled": "false",
+	}
+	namespaceName, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Len(networkPolicy.Spec.Ingress, 1)
+	rule := networkPolicy.Spec.Ingress[0]
+	require.Equal(map[string]string{"kubernetes.io/metadata.name": namespaceName}, rule.From[0].NamespaceSelector.MatchLabels)
+	require.Equal(int32(8000), rule.Ports[0].Port.IntVal)
+}
+
+func TestNetworkPolicyDisabledIngressesEntry(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled":    "true",
+		"metrics.enabled":          "false",
+		"ingresses.public.enabled": "false",
+	}
+	_, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Empty(networkPolicy.Spec.Ingress)
+}
+
+func TestNetworkPolicyGatewayRoutes(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	for _, route := range []string{"httpRoute", "grpcRoute"} {
+		values := map[string]string{
+			"networkPolicy.enabled":                    "true",
+			"networkPolicy.ingressControllerNamespace": "gateway-system",
+			"metrics.enabled":                          "false",
+			route + ".enabled":                         "true",
+			route + ".parentRefs[0].name":              "public",
+		}
+		_, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+		require.Len(networkPolicy.Spec.Ingress, 1, route)
+		rule := networkPolicy.Spec.Ingress[0]
+		require.Equal(map[string]string{"kubernetes.io/metadata.name": "gateway-system"}, rule.From[0].NamespaceSelector.MatchLabels, route)
+		require.Equal(int32(8000), rule.Ports[0].Port.IntVal, route)
+	}
+}
+
+func TestNetworkPolicyPortsFromApplication(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled":                    "true",
+		"networkPolicy.ingressControllerNamespace": "ingress-nginx",
+		"networkPolicy.monitoringNamespace":        "observability",
+		"ingress.enabled":                          "true",
+		"application.serverPort":                   "3000",
+		"application.managementPort":               "3001",
+		"metrics.port":                             "3001",
+	}
+	_, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Len(networkPolicy.Spec.Ingress, 2)
+	require.Equal("ingress-nginx", networkPolicy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	require.Equal(int32(3000), networkPolicy.Spec.Ingress[0].Ports[0].Port.IntVal)
+	require.Equal("observability", networkPolicy.Spec.Ingress[1].From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	require.Equal(int32(3001), networkPolicy.Spec.Ingress[1].Ports[0].Port.IntVal)
+}
+
+func TestNetworkPolicyExposedPorts(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled":  "true",
+		"ingress.enabled":        "true",
+		"ports[0].name":          "http",
+		"ports[0].containerPort": "8080",
+		"ports[0].servicePort":   "80",
+		"ports[1].name":          "grpc",
+		"ports[1].containerPort": "9090",
+		"ports[2].name":          "admin",
+		"ports[2].containerPort": "9901",
+		"ports[2].expose":        "false",
+		"metrics.port":           "9901",
+	}
+	_, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Len(networkPolicy.Spec.Ingress, 2)
+	ingressController := networkPolicy.Spec.Ingress[0].Ports
+	require.Len(ingressController, 2)
+	require.Equal(int32(8080), ingressController[0].Port.IntVal)
+	require.Equal(int32(9090), ingressController[1].Port.IntVal)
+	require.Equal(v1.ProtocolTCP, *ingressController[1].Protocol)
+	monitoring := networkPolicy.Spec.Ingress[1].Ports
+	require.Len(monitoring, 1)
+	require.Equal(int32(9901), monitoring[0].Port.IntVal)
+}
+
+func TestNetworkPolicyDenyAllIngress(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled": "true",
+		"metrics.enabled":       "false",
+	}
+	_, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, networkPolicy.Spec.PolicyTypes)
+	require.Empty(networkPolicy.Spec.Ingress)
+}
+
+func TestNetworkPolicyExtraRules(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"networkPolicy.enabled": "true",
+		"networkPolicy.ingress[0].from[0].podSelector.matchLabels.role":                               "frontend",
+		"networkPolicy.egress[0].to[0].namespaceSelector.matchLabels.kubernetes\\.io/metadata\\.name": "kube-system",
+		"networkPolicy.egress[0].ports[0].port":                                                       "53",
+		"networkPolicy.egress[0].ports[0].protocol":                                                   "UDP",
+	}
+	_, _, networkPolicy := givenANetworkPolicyTemplateWithHelm(t, require, values)
+
+	require.Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}, networkPolicy.Spec.PolicyTypes)
+
+	require.Len(networkPolicy.Spec.Ingress, 2)
+	require.Equal(map[string]string{"role": "frontend"}, networkPolicy.Spec.Ingress[1].From[0].PodSelector.MatchLabels)
+
+	require.Len(networkPolicy.Spec.Egress, 1)
+	egress := networkPolicy.Spec.Egress[0]
+	require.Equal(map[string]string{"kubernetes.io/metadata.name": "kube-system"}, egress.To[0].NamespaceSelector.MatchLabels)
+	require.Equal(int32(53), egress.Ports[0].Port.IntVal)
+	require.Equal(v1.ProtocolUDP, *egress.Ports[0].Protocol)
+}
+
+func TestNetworkPolicyDisabled(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      map[string]string{},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/networkpolicy.yaml"})
+
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/networkpolicy.yaml in chart")
+}
@@ -0,0 +1,171 @@
+package pdb
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/api/policy/v1beta1"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAPdbTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, policyv1.PodDisruptionBudget) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/pdb.yaml"}, "--kube-version=v1.21.0")
+
+	var pdb policyv1.PodDisruptionBudget
+	helm.UnmarshalK8SYaml(t, output, &pdb)
+	return releaseName, pdb
+}
+
+func whenRenderingAnInvalidPdb(t *testing.T, require *require.Assertions, values map[string]string) error {
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/pdb.yaml"}, "--kube-version=v1.21.0")
+	return err
+}
+
+func TestPdbBasic(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"podDisruptionBudget.enabled": "true",
+	}
+	releaseName, pdb := givenAPdbTemplateWithHelm(t, require, values)
+
+	require.Equal("policy/v1", pdb.APIVersion)
+	require.Equal(releaseName+"-chart-test", pdb.Name)
+	require.Nil(pdb.Spec.MinAvailable)
+	require.Equal(int32(1), pdb.Spec.MaxUnavailable.IntVal)
+
+	selector := pdb.Spec.Selector.MatchLabels
+	require.Len(selector, 2)
+	require.Equal("chart-test", selector["app.kubernetes.io/name"])
+	require.Equal("helm-basic", selector["app.kubernetes.io/instance"])
+}
+
+func TestPdbApi20(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"podDisruptionBudget.enabled": "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/pdb.yaml"}, "--kube-version=v1.20.0")
+
+	var pdb v1beta1.PodDisruptionBudget
+	helm.UnmarshalK8SYaml(t, output, &pdb)
+
+	require.Equal("policy/v1beta1", pdb.APIVersion)
+	require.Equal(int32(1), pdb.Spec.MaxUnavailable.IntVal)
+}
+
+func TestPdbMinAvailable(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"podDisruptionBudget.enabled":      "true",
+		"podDisruptionBudget.minAvailable": "2",
+		"replicaCount":                     "3",
+	}
+	_, pdb := givenAPdbTemplateWithHelm(t, require, values)
+
+	require.Equal(int32(2), pdb.Spec.MinAvailable.IntVal)
+	require.Nil(pdb.Spec.MaxUnavailable)
+}
+
+func TestPdbMaxUnavailablePercentage(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"podDisruptionBudget.enabled":        "true",
+		"podDisruptionBudget.maxUnavailable": "30%",
+	}
+	_, pdb := givenAPdbTemplateWithHelm(t, require, values)
+
+	require.Nil(pdb.Spec.MinAvailable)
+	require.Equal("30%", pdb.Spec.MaxUnavailable.StrVal)
+}
+
+func TestPdbBothMinAvailableAndMaxUnavailable(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"podDisruptionBudget.enabled":        "true",
+		"podDisruptionBudget.minAvailable":   "1",
+		"podDisruptionBudget.maxUnavailable": "1",
+		"replicaCount":                       "3",
+	}
+	err := whenRenderingAnInvalidPdb(t, require, values)
+
+	require.Error(err)
+	require.Contains(err.Error(), "Invalid podDisruptionBudget, only one of (minAvailable,maxUnavailable) can be set")
+}
+
+func TestPdbBlockingSingleReplica(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]map[string]string{
+		"minAvailable":           {"podDisruptionBudget.minAvailable": "1"},
+		"minAvailablePercentage": {"podDisruptionBudget.minAvailable": "50%"},
+		"maxUnavailable":         {"podDisruptionBudget.maxUnavailable": "0"},
+	}
+	for name, values := range testCases {
+		values := values
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require := require.New(t)
+
+			values["podDisruptionBudget.enabled"] = "true"
+			err := whenRenderingAnInvalidPdb(t, require, values)
+
+			require.Error(err)
+			require.Contains(err.Error(), "Invalid podDisruptionBudget, it would block all evictions with a single replica (replicaCount: 1)")
+		})
+	}
+}
+
+func TestPdbSingleReplicaWithAutoscaling(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"podDisruptionBudget.enabled":      "true",
+		"podDisruptionBudget.minAvailable": "1",
+		"autoscaling.enabled":              "true",
+		"autoscaling.minReplicas":          "2",
+	}
+	_, pdb := givenAPdbTemplateWithHelm(t, require, values)
+
+	require.Equal(int32(1), pdb.Spec.MinAvailable.IntVal)
+}
@@ -0,0 +1,184 @@
+package prometheusrule
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const monitoringApiVersion = "--api-versions=monitoring.coreos.com/v1"
+
+func givenAPrometheusRuleTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string, valuesFiles []string) (string, string, unstructured.Unstructured) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		ValuesFiles:    valuesFiles,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/prometheusrule.yaml"}, monitoringApiVersion)
+
+	var prometheusRule unstructured.Unstructured
+	helm.UnmarshalK8SYaml(t, output, &prometheusRule)
+	return namespaceName, releaseName, prometheusRule
+}
+
+func rulesOfGroup(require *require.Assertions, prometheusRule unstructured.Unstructured, groupName string) []map[string]interface{} {
+	groups, _, err := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+	require.NoError(err)
+	for _, group := range groups {
+		if group.(map[string]interface{})["name"] == groupName {
+			var rules []map[string]interface{}
+			for _, rule := range group.(map[string]interface{})["rules"].([]interface{}) {
+				rules = append(rules, rule.(map[string]interface{}))
+			}
+			return rules
+		}
+	}
+	require.Failf("group not found", "group %s is not rendered", groupName)
+	return nil
+}
+
+func TestPrometheusRuleDefaultAlerts(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"prometheusRule.enabled": "true",
+	}
+	namespaceName, releaseName, prometheusRule := givenAPrometheusRuleTemplateWithHelm(t, require, values, nil)
+
+	fullName := releaseName + "-chart-test"
+	require.Equal("PrometheusRule", prometheusRule.GetKind())
+	require.Equal(fullName, prometheusRule.GetName())
+
+	rules := rulesOfGroup(require, prometheusRule, fullName+"-default-alerts")
+	require.Len(rules, 2)
+
+	require.Equal("PodCrashLooping", rules[0]["alert"])
+	require.Contains(rules[0]["expr"], `namespace="`+namespaceName+`", pod=~"`+fullName+`-([a-z0-9]{5,10}-[a-z0-9]{5}|[a-z0-9]{5}|[0-9]+)"`)
+	require.Equal("15m", rules[0]["for"])
+	require.Equal(map[string]interface{}{"severity": "warning"}, rules[0]["labels"])
+	require.Contains(rules[0]["annotations"].(map[string]interface{})["description"], "{{ $labels.pod }}")
+
+	require.Equal("DeploymentReplicasMismatch", rules[1]["alert"])
+	require.Contains(rules[1]["expr"], `kube_deployment_spec_replicas{namespace="`+namespaceName+`", deployment="`+fullName+`"}`)
+}
+
+func TestPrometheusRuleToggleDefaultAlerts(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"prometheusRule.enabled":                                "true",
+		"prometheusRule.defaultAlerts.podCrashLooping.enabled":  "false",
+		"prometheusRule.defaultAlerts.replicasMismatch.enabled": "false",
+		"prometheusRule.defaultAlerts.cronJobFailed.enabled":    "true",
+		"prometheusRule.defaultAlerts.cronJobFailed.severity":   "critical",
+	}
+	namespaceName, releaseName, prometheusRule := givenAPrometheusRuleTemplateWithHelm(t, require, values, nil)
+
+	fullName := releaseName + "-chart-test"
+	rules := rulesOfGroup(require, prometheusRule, fullName+"-default-alerts")
+	require.Len(rules, 1)
+	require.Equal("CronJobFailed", rules[0]["alert"])
+	require.Equal(`kube_job_status_failed{namespace="`+namespaceName+`", job_name=~"`+fullName+`-[0-9]+"} > 0`, rules[0]["expr"])
+	require.Equal(map[string]interface{}{"severity": "critical"}, rules[0]["labels"])
+}
+
+func TestPrometheusRuleQuotesTheName(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"prometheusRule.enabled":                             "true",
+		"prometheusRule.defaultAlerts.cronJobFailed.enabled": "true",
+		"fullnameOverride":                                   "api.v2",
+	}
+	_, _, prometheusRule := givenAPrometheusRuleTemplateWithHelm(t, require, values, nil)
+
+	rules := rulesOfGroup(require, prometheusRule, "api.v2-default-alerts")
+	require.Len(rules, 3)
+	require.Contains(rules[0]["expr"], `pod=~"api\\.v2-(`)
+	require.Contains(rules[1]["expr"], `deployment="api.v2"`)
+	require.Contains(rules[2]["expr"], `job_name=~"api\\.v2-[0-9]+"`)
+}
+
+func TestPrometheusRuleWithoutRules(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"prometheusRule.enabled":                                "true",
+			"prometheusRule.defaultAlerts.podCrashLooping.enabled":  "false",
+			"prometheusRule.defaultAlerts.replicasMismatch.enabled": "false",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/prometheusrule.yaml"}, monitoringApiVersion)
+
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/prometheusrule.yaml in chart")
+}
+
+func TestPrometheusRuleTemplatedGroups(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"prometheusRule.defaultAlerts.podCrashLooping.enabled":  "false",
+		"prometheusRule.defaultAlerts.replicasMismatch.enabled": "false",
+		"metrics.path": "/actuator/prometheus",
+	}
+	namespaceName, releaseName, prometheusRule := givenAPrometheusRuleTemplateWithHelm(t, require, values, []string{"values-rules.yaml"})
+
+	fullName := releaseName + "-chart-test"
+	require.Equal("prometheus", prometheusRule.GetLabels()["release"])
+
+	groups, _, err := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+	require.NoError(err)
+	require.Len(groups, 1)
+
+	rules := rulesOfGroup(require, prometheusRule, fullName+".rules")
+	require.Len(rules, 1)
+	require.Equal("MetricsEndpointDown", rules[0]["alert"])
+	require.Equal(`up{namespace="`+namespaceName+`", service="`+fullName+`"} == 0`, rules[0]["expr"])
+	annotations := rules[0]["annotations"].(map[string]interface{})
+	require.Equal("Metrics endpoint /actuator/prometheus is down", annotations["summary"])
+	require.Equal("{{ $labels.pod }} does not serve metrics", annotations["description"])
+}
+
+func TestPrometheusRuleWithoutOperatorApi(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"prometheusRule.enabled": "true",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/prometheusrule.yaml"})
+
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/prometheusrule.yaml in chart")
+}
@@ -0,0 +1,132 @@
+package secret
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAnExternalSecretTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, unstructured.Unstructured) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/secret.yaml"})
+
+	var externalSecret unstructured.Unstructured
+	helm.UnmarshalK8SYaml(t, output, &externalSecret)
+	return releaseName, externalSecret
+}
+
+func TestExternalSecret(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.externalSecret.enabled":             "true",
+		"env.externalSecret.secretStoreRef.name": "vault-backend",
+		"env.externalSecret.secretStoreRef.kind": "ClusterSecretStore",
+		"env.externalSecret.refreshInterval":     "15m",
+		"env.secret.DB_PASSWORD":                 "db/credentials#password",
+		"env.secret.API_TOKEN.key":               "api",
+		"env.secret.API_TOKEN.property":          "token",
+		"env.secret.API_TOKEN.decodingStrategy":  "Base64",
+		"env.secret.LICENSE":                     "license",
+	}
+	releaseName, externalSecret := givenAnExternalSecretTemplateWithHelm(t, require, values)
+
+	require.Equal("external-secrets.io/v1beta1", externalSecret.GetAPIVersion())
+	require.Equal("ExternalSecret", externalSecret.GetKind())
+	require.Equal(releaseName+"-chart-test-env", externalSecret.GetName())
+	require.Equal("chart-test", externalSecret.GetLabels()["app.kubernetes.io/name"])
+
+	spec := externalSecret.Object["spec"].(map[string]interface{})
+	require.Equal("15m", spec["refreshInterval"])
+	require.Equal(map[string]interface{}{"name": "vault-backend", "kind": "ClusterSecretStore"}, spec["secretStoreRef"])
+	require.Equal(map[string]interface{}{"name": releaseName + "-chart-test-env", "creationPolicy": "Owner"}, spec["target"])
+	require.Equal([]interface{}{
+		map[string]interface{}{"secretKey": "API_TOKEN", "remoteRef": map[string]interface{}{"key": "api", "property": "token", "decodingStrategy": "Base64"}},
+		map[string]interface{}{"secretKey": "DB_PASSWORD", "remoteRef": map[string]interface{}{"key": "db/credentials", "property": "password"}},
+		map[string]interface{}{"secretKey": "LICENSE", "remoteRef": map[string]interface{}{"key": "license"}},
+	}, spec["data"])
+}
+
+func TestExternalSecretIsReferencedByTheEnvVars(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"env.externalSecret.enabled":             "true",
+			"env.externalSecret.secretStoreRef.name": "vault-backend",
+			"env.secret.DB_PASSWORD":                 "db/credentials#password",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	found := false
+	for _, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+		if envVar.Name == "DB_PASSWORD" {
+			found = true
+			require.Equal("helm-basic-chart-test-env", envVar.ValueFrom.SecretKeyRef.Name)
+			require.Equal("DB_PASSWORD", envVar.ValueFrom.SecretKeyRef.Key)
+		}
+	}
+	require.True(found)
+}
+
+func TestExternalSecretInvalidSecretStore(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	tests := map[string]struct {
+		values   map[string]string
+		errorMsg string
+	}{
+		"missing name": {
+			values:   map[string]string{},
+			errorMsg: "Invalid env.externalSecret, secretStoreRef.name must be set",
+		},
+		"invalid kind": {
+			values: map[string]string{
+				"env.externalSecret.secretStoreRef.name": "vault-backend",
+				"env.externalSecret.secretStoreRef.kind": "Vault",
+			},
+			errorMsg: "Invalid env.externalSecret.secretStoreRef.kind, must be one of (SecretStore,ClusterSecretStore)",
+		},
+	}
+
+	for name, test := range tests {
+		test.values["env.externalSecret.enabled"] = "true"
+		test.values["env.secret.DB_PASSWORD"] = "db/credentials#password"
+		options := &helm.Options{
+			SetValues:      test.values,
+			KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+		}
+
+		_, err := helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/secret.yaml"})
+		require.Error(err, name)
+		require.Contains(err.Error(), test.errorMsg, name)
+	}
+}
@@ -38,11 +38,13 @@ func TestSecretBasic(t *testing.T) {
 		"env.secret.SECRET_1": "aaa",
 		"env.secret.SECRET_2": "bbb",
 	}
-	_, secret := givenASecretTemplateWithHelm(t, require, values)
+	releaseName, secret := givenASecretTemplateWithHelm(t, require, values)
 
-	require.Equal("app-env-secret", secret.Name)
+	require.Equal(releaseName+"-chart-test-env", secret.Name)
 	require.Empty(secret.Annotations)
-	require.Empty(secret.Labels)
+	require.Equal("chart-test", secret.Labels["app.kubernetes.io/name"])
+	require.Equal("Helm", secret.Labels["app.kubernetes.io/managed-by"])
+	require.Equal("chart-test-0.1.0", secret.Labels["helm.sh/chart"])
 	require.Equal(v1.SecretType("Opaque"), secret.Type)
 
 	require.Len(secret.Data, 2)
@@ -73,9 +75,9 @@ func TestSecretAdvancedWithSubstitution(t *testing.T) {
 	t.Log(len(secret.Data))
 	t.Log(len(secret.Data))
 
-	assertions.Equal("app-env-secret", secret.Name)
+	assertions.Equal(releaseName+"-chart-test-env", secret.Name)
 	assertions.Empty(secret.Annotations)
-	assertions.Empty(secret.Labels)
+	assertions.Equal("chart-test", secret.Labels["app.kubernetes.io/name"])
 	assertions.Equal(v1.SecretType("Opaque"), secret.Type)
 
 	assertions.Len(secret.Data, 4)
@@ -85,3 +87,113 @@ func TestSecretAdvancedWithSubstitution(t *testing.T) {
 	assertions.Equal("aaa", string(secret.Data["SECRET_4"]))
 
 }
+
+func TestSecretCommonLabels(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.secret.SECRET_1":      "aaa",
+		"commonLabels.cost-center": "team-a",
+	}
+	_, secret := givenASecretTemplateWithHelm(t, require, values)
+
+	require.Equal("team-a", secret.Labels["cost-center"])
+	require.Equal("chart-test", secret.Labels["app.kubernetes.io/name"])
+}
+
+func TestSecretExplicitName(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.secret.SECRET_1": "aaa",
+		"appEnvSecret.name":   "app-env-secret",
+	}
+	_, secret := givenASecretTemplateWithHelm(t, require, values)
+
+	require.Equal("app-env-secret", secret.Name)
+}
+
+func TestSecretSettings(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"env.secret.SECRET_1":             "aaa",
+		"appEnvSecret.stringData":         "true",
+		"appEnvSecret.immutable":          "true",
+		"appEnvSecret.annotations.owner":  "payments",
+		"appEnvSecret.labels.secret-type": "env",
+	}
+	_, secret := givenASecretTemplateWithHelm(t, require, values)
+
+	require.Equal(v1.SecretTypeOpaque, secret.Type)
+	require.True(*secret.Immutable)
+	require.Equal(map[string]string{"owner": "payments"}, secret.Annotations)
+	require.Equal("env", secret.Labels["secret-type"])
+	require.Equal("chart-test", secret.Labels["app.kubernetes.io/name"])
+	require.Empty(secret.Data)
+	require.Equal(map[string]string{"SECRET_1": "aaa"}, secret.StringData)
+}
+
+func TestSecretInvalidType(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"env.secret.SECRET_1": "aaa",
+			"appEnvSecret.type":   "kubernetes.io/ssh-auth",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/secret.yaml"})
+	require.Error(err)
+	require.Contains(err.Error(), "Invalid Secret type, must be one of (Opaque,kubernetes.io/dockerconfigjson,kubernetes.io/tls,kubernetes.io/basic-auth)")
+}
+
+func TestExtraSecrets(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	options := &helm.Options{
+		ValuesFiles:    []string{"values-extra-secrets.yaml"},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/secret.yaml"})
+
+	secrets := map[string]v1.Secret{}
+	for _, document := range strings.Split(output, "\n---\n") {
+		var secret v1.Secret
+		helm.UnmarshalK8SYaml(t, document, &secret)
+		secrets[secret.Name] = secret
+	}
+	require.Len(secrets, 3)
+
+	registry := secrets[releaseName+"-chart-test-registry"]
+	require.Equal(v1.SecretTypeDockerConfigJson, registry.Type)
+	require.JSONEq(`{"auths":{"ghcr.io":{"username":"deploy-bot","password":"s3cr3t","auth":"ZGVwbG95LWJvdDpzM2NyM3Q="}}}`, string(registry.Data[".dockerconfigjson"]))
+
+	tls := secrets["wildcard-tls"]
+	require.Equal(v1.SecretTypeTLS, tls.Type)
+	require.True(*tls.Immutable)
+	require.Equal("apps-.*", tls.Annotations["replicator.v1.mittwald.de/replicate-to"])
+	require.Equal("certificate", string(tls.Data["tls.crt"]))
+	require.Equal("key", string(tls.Data["tls.key"]))
+
+	basicAuth := secrets[releaseName+"-chart-test-basic-auth"]
+	require.Equal(v1.SecretTypeBasicAuth, basicAuth.Type)
+	require.Nil(basicAuth.Immutable)
+	require.Equal("payments", basicAuth.Labels["team"])
+	require.Equal(map[string]string{"username": "admin", "password": releaseName + "-password"}, basicAuth.StringData)
+}
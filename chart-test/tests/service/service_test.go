@@ -92,3 +92,114 @@ func TestServiceHeadless(t *testing.T) {
 
 	require.Equal(v1.ServiceType("None"), service.Spec.Type)
 }
+
+func TestServiceMetricsPort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	defaultValues := map[string]string{
+		"metrics.serviceMonitor.enabled": "true",
+	}
+	_, service := givenAServiceTemplateWithHelm(t, require, defaultValues)
+
+	servicePorts := service.Spec.Ports
+	require.Len(servicePorts, 2)
+	require.Equal("metrics", servicePorts[1].Name)
+	require.Equal(int32(9000), servicePorts[1].Port)
+	require.Equal(int32(9000), servicePorts[1].TargetPort.IntVal)
+}
+
+func TestServiceNamedPorts(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	defaultValues := map[string]string{
+		"ports[0].name":          "grpc",
+		"ports[0].containerPort": "9090",
+		"ports[0].appProtocol":   "grpc",
+		"ports[1].name":          "admin",
+		"ports[1].containerPort": "9901",
+		"ports[1].expose":        "false",
+		"ports[2].name":          "dns",
+		"ports[2].containerPort": "53",
+		"ports[2].servicePort":   "5353",
+		"ports[2].protocol":      "UDP",
+	}
+	_, service := givenAServiceTemplateWithHelm(t, require, defaultValues)
+
+	grpc := "grpc"
+	servicePorts := service.Spec.Ports
+	require.Len(servicePorts, 2)
+	require.Equal("grpc", servicePorts[0].Name)
+	require.Equal(int32(9090), servicePorts[0].Port)
+	require.Equal("grpc", servicePorts[0].TargetPort.StrVal)
+	require.Equal(v1.ProtocolTCP, servicePorts[0].Protocol)
+	require.Equal(&grpc, servicePorts[0].AppProtocol)
+	require.Equal("dns", servicePorts[1].Name)
+	require.Equal(int32(5353), servicePorts[1].Port)
+	require.Equal("dns", servicePorts[1].TargetPort.StrVal)
+	require.Equal(v1.ProtocolUDP, servicePorts[1].Protocol)
+	require.Nil(servicePorts[1].AppProtocol)
+}
+
+func TestServiceNamedMetricsPort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	defaultValues := map[string]string{
+		"metrics.serviceMonitor.enabled": "true",
+		"ports[0].name":                  "http",
+		"ports[0].containerPort":         "8000",
+		"ports[1].name":                  "metrics",
+		"ports[1].containerPort":         "9000",
+	}
+	_, service := givenAServiceTemplateWithHelm(t, require, defaultValues)
+
+	servicePorts := service.Spec.Ports
+	require.Len(servicePorts, 2)
+	require.Equal("metrics", servicePorts[1].Name)
+	require.Equal("metrics", servicePorts[1].TargetPort.StrVal)
+}
+
+func TestServiceMetricsPortAlreadyExposed(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"metrics.serviceMonitor.enabled": "true",
+		"metrics.port":                   "8000",
+	}
+	_, service := givenAServiceTemplateWithHelm(t, require, values)
+
+	servicePorts := service.Spec.Ports
+	require.Len(servicePorts, 1)
+	require.Equal("http", servicePorts[0].Name)
+	require.Equal(int32(8000), servicePorts[0].Port)
+}
+
+func TestServiceMetricsPortTakenByAnotherPort(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"metrics.serviceMonitor.enabled": "true",
+			"ports[0].name":                  "http",
+			"ports[0].containerPort":         "8080",
+			"ports[0].servicePort":           "9000",
+			"ports[1].name":                  "mgmt",
+			"ports[1].containerPort":         "9000",
+			"ports[1].expose":                "false",
+			"metrics.port":                   "9000",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/service.yaml"})
+
+	require.Error(err)
+	require.Contains(err.Error(), "Invalid metrics.port, the service port 9000 is used by the port http")
+}
@@ -0,0 +1,82 @@
+package serviceaccount
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func givenAServiceAccountTemplateWithHelm(t *testing.T, require *require.Assertions, values map[string]string) (string, v1.ServiceAccount) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName := "helm-basic"
+	require.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/serviceaccount.yaml"})
+
+	var serviceAccount v1.ServiceAccount
+	helm.UnmarshalK8SYaml(t, output, &serviceAccount)
+	return releaseName, serviceAccount
+}
+
+func TestServiceAccountBasic(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"serviceAccount.create": "true",
+	}
+	releaseName, serviceAccount := givenAServiceAccountTemplateWithHelm(t, require, values)
+
+	require.Equal(releaseName+"-chart-test", serviceAccount.Name)
+	require.Empty(serviceAccount.Annotations)
+	require.Equal("chart-test", serviceAccount.Labels["app.kubernetes.io/name"])
+	require.Nil(serviceAccount.AutomountServiceAccountToken)
+}
+
+func TestServiceAccountNotCreated(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	require.NoError(err)
+
+	options := &helm.Options{
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/serviceaccount.yaml"})
+
+	require.Error(err)
+	require.Contains(err.Error(), "could not find template templates/serviceaccount.yaml in chart")
+}
+
+func TestServiceAccountWorkloadIdentity(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	values := map[string]string{
+		"serviceAccount.create": "true",
+		"serviceAccount.name":   "custom-sa",
+		"serviceAccount.annotations.\"eks\\.amazonaws\\.com/role-arn\"":     "arn:aws:iam::111122223333:role/my-role",
+		"serviceAccount.annotations.\"iam\\.gke\\.io/gcp-service-account\"": "app@project.iam.gserviceaccount.com",
+		"serviceAccount.automountServiceAccountToken":                       "false",
+	}
+	_, serviceAccount := givenAServiceAccountTemplateWithHelm(t, require, values)
+
+	require.Equal("custom-sa", serviceAccount.Name)
+	require.Equal("arn:aws:iam::111122223333:role/my-role", serviceAccount.Annotations["eks.amazonaws.com/role-arn"])
+	require.Equal("app@project.iam.gserviceaccount.com", serviceAccount.Annotations["iam.gke.io/gcp-service-account"])
+	require.False(*serviceAccount.AutomountServiceAccountToken)
+}
@@ -0,0 +1,300 @@
+package sidecar
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func renderTemplate(t *testing.T, assertions *require.Assertions, template string, values map[string]string, kubeVersion string) (string, string) {
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		ValuesFiles:    []string{"values-sidecars.yaml"},
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{template}, "--kube-version="+kubeVersion)
+	return namespaceName, output
+}
+
+func TestDeploymentSidecars(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	namespaceName, output := renderTemplate(t, assertions, "templates/deployment.yaml", map[string]string{}, "v1.29.0")
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	podSpec := deployment.Spec.Template.Spec
+	assertions.Len(podSpec.Containers, 2)
+	assertions.Equal("chart-test", podSpec.Containers[0].Name)
+
+	envoy := podSpec.Containers[1]
+	assertions.Equal("envoy", envoy.Name)
+	assertions.Equal("envoyproxy/envoy:v1.30.1", envoy.Image)
+	assertions.Equal(v1.PullIfNotPresent, envoy.ImagePullPolicy)
+	assertions.Nil(envoy.Command)
+	assertions.Equal([]string{"--config-path", "/etc/envoy/envoy.yaml"}, envoy.Args)
+
+	assertions.Len(envoy.Env, 5)
+	assertions.Contains(envoy.Env, v1.EnvVar{Name: "ENVOY_UID", Value: "0"})
+	assertions.Contains(envoy.Env, v1.EnvVar{Name: "UPSTREAM_PORT", Value: "8000"})
+	assertions.Contains(envoy.Env, v1.EnvVar{Name: "ENVOY_TLS_KEY", Value: "vault:k8s/data/" + namespaceName + "/envoy#tls.key"})
+	assertions.Contains(envoy.Env, v1.EnvVar{Name: "ENVOY_ADMIN_TOKEN", ValueFrom: &v1.EnvVarSource{
+		SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "helm-basic-chart-test-env"}, Key: "ENVOY_ADMIN_TOKEN"},
+	}})
+	assertions.Contains(envoy.Env, v1.EnvVar{Name: "ENVOY_LOG_LEVEL", ValueFrom: &v1.EnvVarSource{
+		ConfigMapKeyRef: &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "helm-basic-chart-test-env"}, Key: "ENVOY_LOG_LEVEL"},
+	}})
+	assertions.Equal([]v1.EnvFromSource{
+		{ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "helm-basic-envoy-settings"}}},
+	}, envoy.EnvFrom)
+	assertions.Empty(podSpec.Containers[0].EnvFrom)
+
+	assertions.Equal([]v1.ContainerPort{
+		{Name: "envoy-http", ContainerPort: 10000, Protocol: "TCP"},
+		{Name: "envoy-admin", ContainerPort: 9901, Protocol: "TCP"},
+	}, envoy.Ports)
+
+	assertions.Nil(envoy.StartupProbe)
+	assertions.Nil(envoy.LivenessProbe)
+	assertions.Equal("/ready", envoy.ReadinessProbe.HTTPGet.Path)
+	assertions.Equal(int32(9901), envoy.ReadinessProbe.HTTPGet.Port.IntVal)
+	assertions.Equal(int32(5), envoy.ReadinessProbe.PeriodSeconds)
+	assertions.Equal(int32(1), envoy.ReadinessProbe.SuccessThreshold)
+
+	assertions.Equal(resource.MustParse("50m"), envoy.Resources.Requests[v1.ResourceCPU])
+	assertions.Equal([]v1.VolumeMount{{Name: "envoy-config", MountPath: "/etc/envoy"}}, envoy.VolumeMounts)
+
+	assertions.Equal("https://vault-dev.domain.tld", deployment.Spec.Template.Annotations["vault.security.banzaicloud.io/vault-addr"])
+}
+
+func TestDeploymentNativeSidecar(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	_, output := renderTemplate(t, assertions, "templates/deployment.yaml", map[string]string{}, "v1.29.0")
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	initContainers := deployment.Spec.Template.Spec.InitContainers
+	assertions.Len(initContainers, 1)
+
+	proxy := initContainers[0]
+	assertions.Equal("cloud-sql-proxy", proxy.Name)
+	assertions.Equal("gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.11.0", proxy.Image)
+	assertions.Equal(v1.PullAlways, proxy.ImagePullPolicy)
+	assertions.Equal(int32(5432), proxy.StartupProbe.TCPSocket.Port.IntVal)
+	assertions.Equal([]string{"project:region:instance"}, proxy.Args)
+	assertions.Empty(proxy.Env)
+	assertions.Equal(int32(30), proxy.StartupProbe.FailureThreshold)
+
+	assertions.Equal(v1.ContainerRestartPolicyAlways, *proxy.RestartPolicy)
+	assertions.Nil(deployment.Spec.Template.Spec.Containers[1].RestartPolicy)
+}
+
+func TestSidecarSecurityPreset(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"securityPreset": "restricted",
+	}
+	_, output := renderTemplate(t, assertions, "templates/deployment.yaml", values, "v1.29.0")
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	restricted := v1.SecurityContext{
+		AllowPrivilegeEscalation: boolPointer(false),
+		Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+		ReadOnlyRootFilesystem:   boolPointer(true),
+	}
+	tmpMount := v1.VolumeMount{Name: "tmp", MountPath: "/tmp"}
+
+	proxy := deployment.Spec.Template.Spec.InitContainers[0]
+	assertions.Equal("cloud-sql-proxy", proxy.Name)
+	assertions.Equal(&restricted, proxy.SecurityContext)
+	assertions.Equal([]v1.VolumeMount{tmpMount}, proxy.VolumeMounts)
+
+	envoy := deployment.Spec.Template.Spec.Containers[1]
+	assertions.Equal("envoy", envoy.Name)
+	envoySecurityContext := restricted
+	envoySecurityContext.RunAsUser = int64Pointer(101)
+	assertions.Equal(&envoySecurityContext, envoy.SecurityContext)
+	assertions.Equal([]v1.VolumeMount{tmpMount, {Name: "envoy-config", MountPath: "/etc/envoy"}}, envoy.VolumeMounts)
+}
+
+func TestSidecarSecurityContextWithoutPreset(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	_, output := renderTemplate(t, assertions, "templates/deployment.yaml", map[string]string{}, "v1.29.0")
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, output, &deployment)
+
+	assertions.Nil(deployment.Spec.Template.Spec.InitContainers[0].SecurityContext)
+	assertions.Equal(&v1.SecurityContext{RunAsUser: int64Pointer(101)}, deployment.Spec.Template.Spec.Containers[1].SecurityContext)
+	assertions.Equal([]v1.VolumeMount{{Name: "envoy-config", MountPath: "/etc/envoy"}}, deployment.Spec.Template.Spec.Containers[1].VolumeMounts)
+}
+
+func TestNativeSidecarUnsupportedKubeVersion(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		ValuesFiles:    []string{"values-sidecars.yaml"},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"}, "--kube-version=v1.28.0")
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid sidecar cloud-sql-proxy, native sidecars require Kubernetes 1.29 or newer")
+}
+
+func TestInvalidSidecar(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"sidecars[0].name": "envoy",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/deployment.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid sidecar, name and image must be set")
+}
+
+func TestCronJobSidecars(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	_, output := renderTemplate(t, assertions, "templates/cronjob.yaml", map[string]string{}, "v1.29.0")
+	var cronJob batchV1.CronJob
+	helm.UnmarshalK8SYaml(t, output, &cronJob)
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assertions.Len(podSpec.Containers, 1)
+	assertions.Equal("chart-test", podSpec.Containers[0].Name)
+	assertions.Len(podSpec.InitContainers, 1)
+	assertions.Equal("cloud-sql-proxy", podSpec.InitContainers[0].Name)
+}
+
+func TestJobSidecars(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	_, output := renderTemplate(t, assertions, "templates/job.yaml", map[string]string{}, "v1.29.0")
+	var job batchV1.Job
+	helm.UnmarshalK8SYaml(t, output, &job)
+
+	podSpec := job.Spec.Template.Spec
+	assertions.Len(podSpec.Containers, 1)
+	assertions.Equal("chart-test", podSpec.Containers[0].Name)
+	assertions.Len(podSpec.InitContainers, 1)
+	assertions.Equal("cloud-sql-proxy", podSpec.InitContainers[0].Name)
+	assertions.Equal(v1.ContainerRestartPolicyAlways, *podSpec.InitContainers[0].RestartPolicy)
+}
+
+func TestSidecarEnvStoredInAppSecretAndConfigMap(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"env.secret.DB_PASSWORD":  "pwd",
+		"env.configMap.LOG_LEVEL": "debug",
+	}
+
+	_, output := renderTemplate(t, assertions, "templates/secret.yaml", values, "v1.29.0")
+	var secret v1.Secret
+	helm.UnmarshalK8SYaml(t, output, &secret)
+	assertions.Equal(map[string][]byte{"DB_PASSWORD": []byte("pwd"), "ENVOY_ADMIN_TOKEN": []byte("admin-token")}, secret.Data)
+
+	_, output = renderTemplate(t, assertions, "templates/configmap.yaml", values, "v1.29.0")
+	var configMap v1.ConfigMap
+	helm.UnmarshalK8SYaml(t, output, &configMap)
+	assertions.Equal(map[string]string{"LOG_LEVEL": "debug", "ENVOY_LOG_LEVEL": "info"}, configMap.Data)
+}
+
+func boolPointer(value bool) *bool {
+	return &value
+}
+
+func int64Pointer(value int64) *int64 {
+	return &value
+}
+
+func TestSidecarEnvConflictsWithTheApplication(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	tests := map[string]struct {
+		template string
+		values   map[string]string
+		errorMsg string
+	}{
+		"secret": {
+			template: "templates/secret.yaml",
+			values:   map[string]string{"env.secret.ENVOY_ADMIN_TOKEN": "app-token"},
+			errorMsg: "Invalid sidecar envoy, env.secret.ENVOY_ADMIN_TOKEN conflicts with the value of the application or of another sidecar",
+		},
+		"configMap": {
+			template: "templates/configmap.yaml",
+			values:   map[string]string{"env.configMap.ENVOY_LOG_LEVEL": "debug"},
+			errorMsg: "Invalid sidecar envoy, env.configMap.ENVOY_LOG_LEVEL conflicts with the value of the application or of another sidecar",
+		},
+	}
+
+	for name, test := range tests {
+		options := &helm.Options{
+			ValuesFiles:    []string{"values-sidecars.yaml"},
+			SetValues:      test.values,
+			KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+		}
+
+		_, err := helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{test.template}, "--kube-version=v1.29.0")
+		assertions.Error(err, name)
+		assertions.Contains(err.Error(), test.errorMsg, name)
+	}
+}
+
+func TestSidecarEnvSharedWithTheApplication(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"env.configMap.ENVOY_LOG_LEVEL": "info",
+		"env.configMap.APP_MODE":        "proxy",
+	}
+	_, output := renderTemplate(t, assertions, "templates/configmap.yaml", values, "v1.29.0")
+	var configMap v1.ConfigMap
+	helm.UnmarshalK8SYaml(t, output, &configMap)
+
+	assertions.Equal(map[string]string{"ENVOY_LOG_LEVEL": "info", "APP_MODE": "proxy"}, configMap.Data)
+}
@@ -0,0 +1,244 @@
+package statefulset
+
+import (
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatefulSetBasic(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	statefulSet, service, releaseName := givenAStatefulSetTemplateWithHelm(t, assertions, map[string]string{})
+
+	assertions.Equal(releaseName+"-chart-test", statefulSet.Name)
+
+	assertions.Equal(int32(1), *statefulSet.Spec.Replicas)
+	assertions.Equal(releaseName+"-chart-test-headless", statefulSet.Spec.ServiceName)
+	assertions.Equal(appsv1.OrderedReadyPodManagement, statefulSet.Spec.PodManagementPolicy)
+	assertions.Equal(appsv1.RollingUpdateStatefulSetStrategyType, statefulSet.Spec.UpdateStrategy.Type)
+	assertions.Equal(int32(0), *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	assertions.Equal(int32(0), statefulSet.Spec.MinReadySeconds)
+	assertions.Equal(int32(3), *statefulSet.Spec.RevisionHistoryLimit)
+	assertions.Nil(statefulSet.Spec.PersistentVolumeClaimRetentionPolicy)
+	assertions.Empty(statefulSet.Spec.VolumeClaimTemplates)
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":     "chart-test",
+		"app.kubernetes.io/instance": releaseName,
+	}
+	assertions.Equal(labels, statefulSet.Spec.Selector.MatchLabels)
+	for key, value := range labels {
+		assertions.Equal(value, statefulSet.Spec.Template.Labels[key])
+	}
+	assertions.Equal("Helm", statefulSet.Spec.Template.Labels["app.kubernetes.io/managed-by"])
+
+	deploymentContainers := statefulSet.Spec.Template.Spec.Containers
+	assertions.Equal(len(deploymentContainers), 1)
+	container := deploymentContainers[0]
+	assertions.Equal("nginx:latest", container.Image)
+	assertions.Equal("chart-test", container.Name)
+
+	ports := container.Ports
+	assertions.Equal(2, len(ports))
+	assertions.Contains(ports, v1.ContainerPort{Name: "http", ContainerPort: 8000, Protocol: "TCP"})
+	assertions.Contains(ports, v1.ContainerPort{Name: "health-check", ContainerPort: 9000, Protocol: "TCP"})
+	assertions.Equal("/health", container.LivenessProbe.HTTPGet.Path)
+	assertions.Equal("/health", container.ReadinessProbe.HTTPGet.Path)
+
+	assertions.Equal(releaseName+"-chart-test-headless", service.Name)
+	assertions.Equal(v1.ClusterIPNone, service.Spec.ClusterIP)
+	assertions.False(service.Spec.PublishNotReadyAddresses)
+	assertions.Len(service.Spec.Ports, 1)
+	assertions.Equal("http", service.Spec.Ports[0].Name)
+	assertions.Equal(int32(8000), service.Spec.Ports[0].Port)
+	assertions.Equal(labels, service.Spec.Selector)
+}
+
+func givenAStatefulSetTemplateWithHelm(t *testing.T, assertions *require.Assertions, values map[string]string) (statefulSet appsv1.StatefulSet, service v1.Service, releaseName string) {
+	helmChartPath, err := filepath.Abs("../../")
+	releaseName = "helm-basic"
+	assertions.NoError(err)
+
+	namespaceName := "medieval-" + strings.ToLower(random.UniqueId())
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespaceName),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/statefulset.yaml"})
+
+	for _, document := range strings.Split(output, "\n---\n") {
+		if strings.Contains(document, "kind: StatefulSet") {
+			helm.UnmarshalK8SYaml(t, document, &statefulSet)
+		}
+		if strings.Contains(document, "kind: Service\n") {
+			helm.UnmarshalK8SYaml(t, document, &service)
+		}
+	}
+	return statefulSet, service, releaseName
+}
+
+func TestStatefulSetCustomValues(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"replicaCount":                                                 "3",
+		"statefulSet.podManagementPolicy":                              "Parallel",
+		"statefulSet.updateStrategy.rollingUpdate.partition":           "2",
+		"statefulSet.minReadySeconds":                                  "10",
+		"statefulSet.revisionHistoryLimit":                             "5",
+		"statefulSet.persistentVolumeClaimRetentionPolicy.whenDeleted": "Delete",
+		"statefulSet.persistentVolumeClaimRetentionPolicy.whenScaled":  "Retain",
+		"statefulSet.publishNotReadyAddresses":                         "true",
+	}
+	statefulSet, service, _ := givenAStatefulSetTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal(int32(3), *statefulSet.Spec.Replicas)
+	assertions.Equal(appsv1.ParallelPodManagement, statefulSet.Spec.PodManagementPolicy)
+	assertions.Equal(int32(2), *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	assertions.Equal(int32(10), statefulSet.Spec.MinReadySeconds)
+	assertions.Equal(int32(5), *statefulSet.Spec.RevisionHistoryLimit)
+	assertions.Equal(appsv1.DeletePersistentVolumeClaimRetentionPolicyType, statefulSet.Spec.PersistentVolumeClaimRetentionPolicy.WhenDeleted)
+	assertions.Equal(appsv1.RetainPersistentVolumeClaimRetentionPolicyType, statefulSet.Spec.PersistentVolumeClaimRetentionPolicy.WhenScaled)
+	assertions.True(service.Spec.PublishNotReadyAddresses)
+}
+
+func TestStatefulSetOnDeleteStrategy(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"statefulSet.updateStrategy.type": "OnDelete",
+	}
+	statefulSet, _, _ := givenAStatefulSetTemplateWithHelm(t, assertions, values)
+
+	assertions.Equal(appsv1.OnDeleteStatefulSetStrategyType, statefulSet.Spec.UpdateStrategy.Type)
+	assertions.Nil(statefulSet.Spec.UpdateStrategy.RollingUpdate)
+}
+
+func TestStatefulSetInvalidStrategy(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"statefulSet.updateStrategy.type": "Recreate",
+	}
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/statefulset.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid updateStrategy type, must be one of (RollingUpdate,OnDelete)")
+}
+
+func TestStatefulSetInvalidPodManagementPolicy(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"statefulSet.podManagementPolicy": "Random",
+	}
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "helm-basic", []string{"templates/statefulset.yaml"})
+
+	assertions.Error(err)
+	assertions.Contains(err.Error(), "Invalid podManagementPolicy, must be one of (OrderedReady,Parallel)")
+}
+
+func TestStatefulSetVolumeClaimTemplates(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"statefulSet.volumeClaimTemplates[0].metadata.name":                   "data",
+		"statefulSet.volumeClaimTemplates[0].spec.accessModes[0]":             "ReadWriteOnce",
+		"statefulSet.volumeClaimTemplates[0].spec.storageClassName":           "fast",
+		"statefulSet.volumeClaimTemplates[0].spec.resources.requests.storage": "1Gi",
+		"extraVolumeMounts": "- name: data\n  mountPath: /var/lib/data",
+	}
+	statefulSet, _, _ := givenAStatefulSetTemplateWithHelm(t, assertions, values)
+
+	claims := statefulSet.Spec.VolumeClaimTemplates
+	assertions.Len(claims, 1)
+	assertions.Equal("data", claims[0].Name)
+	assertions.Equal([]v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}, claims[0].Spec.AccessModes)
+	assertions.Equal("fast", *claims[0].Spec.StorageClassName)
+	assertions.Equal(resource.MustParse("1Gi"), claims[0].Spec.Resources.Requests[v1.ResourceStorage])
+
+	volumeMounts := statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts
+	assertions.Equal([]v1.VolumeMount{{Name: "data", MountPath: "/var/lib/data"}}, volumeMounts)
+}
+
+func TestStatefulSetExistingGoverningService(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"statefulSet.serviceName": "existing-service",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	output := helm.RenderTemplate(t, options, helmChartPath, "helm-basic", []string{"templates/statefulset.yaml"})
+	assertions.NotContains(output, "kind: Service\n")
+
+	var statefulSet appsv1.StatefulSet
+	helm.UnmarshalK8SYaml(t, output, &statefulSet)
+	assertions.Equal("existing-service", statefulSet.Spec.ServiceName)
+}
+
+func TestStatefulSetAutoscalingEnabled(t *testing.T) {
+	t.Parallel()
+	assertions := require.New(t)
+
+	values := map[string]string{
+		"autoscaling.enabled":    "true",
+		"autoscaling.targetKind": "StatefulSet",
+	}
+	statefulSet, _, releaseName := givenAStatefulSetTemplateWithHelm(t, assertions, values)
+
+	assertions.Nil(statefulSet.Spec.Replicas)
+
+	helmChartPath, err := filepath.Abs("../../")
+	assertions.NoError(err)
+	options := &helm.Options{
+		SetValues:      values,
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+	output := helm.RenderTemplate(t, options, helmChartPath, releaseName, []string{"templates/hpa.yaml"}, "--kube-version=v1.23.0")
+
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	helm.UnmarshalK8SYaml(t, output, &hpa)
+	assertions.Equal(autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: statefulSet.Name}, hpa.Spec.ScaleTargetRef)
+}
@@ -0,0 +1,83 @@
+package umbrella
+
+import (
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// givenAnUmbrellaChart copies the umbrella fixture to a temporary folder and
+// vendors helm-common into both microservice subcharts.
+func givenAnUmbrellaChart(t *testing.T, require *require.Assertions) string {
+	helmCommonPath, err := filepath.Abs("../../../charts/helm-common")
+	require.NoError(err)
+
+	umbrellaChartPath, err := files.CopyFolderToTemp("chart", t.Name(), func(string) bool { return true })
+	require.NoError(err)
+
+	for _, subchart := range []string{"ms1", "ms2"} {
+		vendoredPath := filepath.Join(umbrellaChartPath, "charts", subchart, "charts", "helm-common")
+		require.NoError(os.MkdirAll(vendoredPath, 0755))
+		require.NoError(files.CopyFolderContents(helmCommonPath, vendoredPath))
+	}
+	return umbrellaChartPath
+}
+
+func TestUmbrellaEnvObjectsDoNotCollide(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	umbrellaChartPath := givenAnUmbrellaChart(t, require)
+	releaseName := "helm-basic"
+
+	options := &helm.Options{
+		KubectlOptions: k8s.NewKubectlOptions("", "", "medieval-"+strings.ToLower(random.UniqueId())),
+	}
+
+	configMaps := map[string]v1.ConfigMap{}
+	secrets := map[string]v1.Secret{}
+	deployments := map[string]appsv1.Deployment{}
+	for _, subchart := range []string{"ms1", "ms2"} {
+		var configMap v1.ConfigMap
+		output := helm.RenderTemplate(t, options, umbrellaChartPath, releaseName, []string{"charts/" + subchart + "/templates/configmap.yaml"})
+		helm.UnmarshalK8SYaml(t, output, &configMap)
+		configMaps[subchart] = configMap
+
+		var secret v1.Secret
+		output = helm.RenderTemplate(t, options, umbrellaChartPath, releaseName, []string{"charts/" + subchart + "/templates/secret.yaml"})
+		helm.UnmarshalK8SYaml(t, output, &secret)
+		secrets[subchart] = secret
+
+		var deployment appsv1.Deployment
+		output = helm.RenderTemplate(t, options, umbrellaChartPath, releaseName, []string{"charts/" + subchart + "/templates/deployment.yaml"})
+		helm.UnmarshalK8SYaml(t, output, &deployment)
+		deployments[subchart] = deployment
+	}
+
+	require.Equal(releaseName+"-ms1-env", configMaps["ms1"].Name)
+	require.Equal(releaseName+"-ms2-env", configMaps["ms2"].Name)
+	require.Equal("ms1", configMaps["ms1"].Data["SERVICE_NAME"])
+	require.Equal("ms2", configMaps["ms2"].Data["SERVICE_NAME"])
+
+	require.Equal(releaseName+"-ms1-env", secrets["ms1"].Name)
+	require.Equal(releaseName+"-ms2-env", secrets["ms2"].Name)
+	require.Equal("ms1-password", string(secrets["ms1"].Data["PASSWORD"]))
+	require.Equal("ms2-password", string(secrets["ms2"].Data["PASSWORD"]))
+
+	for _, subchart := range []string{"ms1", "ms2"} {
+		envVars := map[string]v1.EnvVar{}
+		for _, envVar := range deployments[subchart].Spec.Template.Spec.Containers[0].Env {
+			envVars[envVar.Name] = envVar
+		}
+		require.Equal(configMaps[subchart].Name, envVars["SERVICE_NAME"].ValueFrom.ConfigMapKeyRef.Name)
+		require.Equal(secrets[subchart].Name, envVars["PASSWORD"].ValueFrom.SecretKeyRef.Name)
+	}
+}
@@ -41,6 +41,39 @@ helm.sh/chart: {{ include "helm-common.chart" . }}
 app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
 {{- end }}
 app.kubernetes.io/managed-by: {{ .Release.Service }}
+{{- with .Values.commonLabels }}
+{{ toYaml . }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Labels of the pod templates
+*/}}
+{{- define "helm-common.podLabels" -}}
+{{ include "helm-common.labels" . }}
+{{- with .Values.podLabels }}
+{{ toYaml . }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Labels of the Job and CronJob pod templates. The selector labels are left out, so the Service, the PodDisruptionBudget,
+the NetworkPolicy and the monitors of the application don't select the batch pods
+*/}}
+{{- define "helm-common.batchPodLabels" -}}
+{{- $context := .context -}}
+helm.sh/chart: {{ include "helm-common.chart" $context }}
+app.kubernetes.io/component: {{ .component }}
+{{- if $context.Chart.AppVersion }}
+app.kubernetes.io/version: {{ $context.Chart.AppVersion | quote }}
+{{- end }}
+app.kubernetes.io/managed-by: {{ $context.Release.Service }}
+{{- with $context.Values.commonLabels }}
+{{ toYaml . }}
+{{- end }}
+{{- with $context.Values.podLabels }}
+{{ toYaml . }}
+{{- end }}
 {{- end -}}
 
 {{/*
@@ -51,11 +84,320 @@ app.kubernetes.io/name: {{ include "helm-common.name" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end -}}
 
+{{/*
+Deep merge the "override" dict into the "base" dict in place. Unlike mergeOverwrite, false, zero
+and empty values of the override are kept
+*/}}
+{{- define "helm-common.mergeValues" -}}
+{{- $base := .base -}}
+{{- range $key, $value := .override -}}
+{{- if and (kindIs "map" $value) (kindIs "map" (get $base $key)) -}}
+{{- include "helm-common.mergeValues" (dict "base" (get $base $key) "override" $value) -}}
+{{- else -}}
+{{- $_ := set $base $key $value -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+"true" if the release is exposed through the ingress controller or a Gateway, by the ingress block,
+an enabled ingresses entry, the HTTPRoute or the GRPCRoute
+*/}}
+{{- define "helm-common.exposed" -}}
+{{- $exposed := or .Values.ingress.enabled .Values.httpRoute.enabled .Values.grpcRoute.enabled -}}
+{{- range .Values.ingresses -}}
+{{- if or (not (hasKey . "enabled")) .enabled -}}
+{{- $exposed = true -}}
+{{- end -}}
+{{- end -}}
+{{- if $exposed -}}true{{- end -}}
+{{- end -}}
+
+{{/*
+Name of the service account used by the pods
+*/}}
+{{- define "helm-common.serviceAccountName" -}}
+{{- if .Values.serviceAccount.create -}}
+{{- default (include "helm-common.fullname" .) .Values.serviceAccount.name -}}
+{{- else -}}
+{{- default "default" (default .Values.global.serviceAccountName .Values.serviceAccount.name) -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Name of the Secret of the sensitive env vars
+*/}}
+{{- define "helm-common.envSecretName" -}}
+{{- default (printf "%s-env" (include "helm-common.fullname" .) | trunc 63 | trimSuffix "-") .Values.appEnvSecret.name -}}
+{{- end -}}
+
+{{/*
+Name of the ConfigMap of the env vars
+*/}}
+{{- define "helm-common.envConfigMapName" -}}
+{{- default (printf "%s-env" (include "helm-common.fullname" .) | trunc 63 | trimSuffix "-") .Values.appEnvConfigMap.name -}}
+{{- end -}}
+
+{{/*
+Entries of the env Secret or ConfigMap ("kind" secret or configMap) as yaml: the env of the application
+and of the sidecars. Fails if a sidecar sets a key to another value than the application or an other sidecar
+*/}}
+{{- define "helm-common.envEntries" -}}
+{{- $kind := .kind -}}
+{{- $context := .context -}}
+{{- $entries := dict -}}
+{{- if $context.Values.env -}}
+{{- $entries = deepCopy (default dict (get $context.Values.env $kind)) -}}
+{{- end -}}
+{{- range $context.Values.sidecars -}}
+{{- $sidecar := . -}}
+{{- if .env -}}
+{{- range $key, $value := default dict (get .env $kind) -}}
+{{- if and (hasKey $entries $key) (ne (toYaml (get $entries $key)) (toYaml $value)) -}}
+{{- fail (printf "Invalid sidecar %s, env.%s.%s conflicts with the value of the application or of another sidecar" $sidecar.name $kind $key) -}}
+{{- end -}}
+{{- $_ := set $entries $key $value -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- toYaml $entries -}}
+{{- end -}}
+
+{{/*
+Name of the ConfigMap of the config files
+*/}}
+{{- define "helm-common.configFilesName" -}}
+{{- default (printf "%s-config" (include "helm-common.fullname" .) | trunc 63 | trimSuffix "-") .Values.configFiles.name -}}
+{{- end -}}
+
+{{/*
+Content of the config files ConfigMap as yaml with "data" and "binaryData" keys. Empty if no config files are configured
+*/}}
+{{- define "helm-common.configFiles" -}}
+{{- $configFiles := .Values.configFiles -}}
+{{- if or $configFiles.files $configFiles.glob $configFiles.binaryData $configFiles.binaryGlob -}}
+{{- $data := dict -}}
+{{- $binaryData := dict -}}
+{{- if $configFiles.glob -}}
+{{- range $path, $file := $.Files.Glob $configFiles.glob -}}
+{{- $_ := set $data (base $path) (tpl (toString $file) $) -}}
+{{- end -}}
+{{- end -}}
+{{- range $key, $val := $configFiles.files -}}
+{{- $_ := set $data $key (tpl (toString $val) $) -}}
+{{- end -}}
+{{- if $configFiles.binaryGlob -}}
+{{- range $path, $file := $.Files.Glob $configFiles.binaryGlob -}}
+{{- $_ := set $binaryData (base $path) (toString $file | b64enc) -}}
+{{- end -}}
+{{- end -}}
+{{- range $key, $val := $configFiles.binaryData -}}
+{{- $_ := set $binaryData $key $val -}}
+{{- end -}}
+{{- /* toYaml drops the trailing newline of the last file otherwise */ -}}
+{{- printf "%s\n" (toYaml (dict "data" $data "binaryData" $binaryData)) -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Scrape settings shared by the ServiceMonitor and PodMonitor endpoints
+*/}}
+{{- define "helm-common.metricsEndpointSettings" -}}
+path: {{ .Values.metrics.path }}
+{{- with .Values.metrics.interval }}
+interval: {{ . }}
+{{- end }}
+{{- with .Values.metrics.scrapeTimeout }}
+scrapeTimeout: {{ . }}
+{{- end }}
+{{- with .Values.metrics.scheme }}
+scheme: {{ . }}
+{{- end }}
+{{- with .Values.metrics.tlsConfig }}
+tlsConfig: {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .Values.metrics.relabelings }}
+relabelings: {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .Values.metrics.metricRelabelings }}
+metricRelabelings: {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Named ports of the application container as a yaml dict with a single "ports" key.
+Defaults to the http and health-check ports when `ports` is not set
+*/}}
+{{- define "helm-common.ports" -}}
+{{- $ports := .Values.ports -}}
+{{- if not $ports -}}
+{{- $http := dict "name" "http" "containerPort" .Values.application.serverPort "servicePort" .Values.service.port -}}
+{{- $healthCheck := dict "name" "health-check" "containerPort" .Values.application.managementPort "expose" false -}}
+{{- $ports = list $http $healthCheck -}}
+{{- end -}}
+{{- range $ports -}}
+{{- if or (not .name) (not .containerPort) -}}
+{{- fail "Invalid ports, name and containerPort must be set" -}}
+{{- end -}}
+{{- end -}}
+{{- toYaml (dict "ports" $ports) -}}
+{{- end -}}
+
+{{/*
+Service ports of the exposed container ports
+*/}}
+{{- define "helm-common.servicePorts" -}}
+{{- range (include "helm-common.ports" . | fromYaml).ports }}
+{{- if or (kindIs "invalid" .expose) .expose }}
+- port: {{ default .containerPort .servicePort }}
+  targetPort: {{ .name }}
+  protocol: {{ default "TCP" .protocol }}
+  name: {{ .name }}
+  {{- with .appProtocol }}
+  appProtocol: {{ . }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Name of the exposed service port serving the metrics: the port named "metrics" or the one with metrics.port as container port.
+Empty if the Service needs an extra metrics port
+*/}}
+{{- define "helm-common.metricsServicePortName" -}}
+{{- $metricsPort := toString .Values.metrics.port -}}
+{{- $named := "" -}}
+{{- $numbered := "" -}}
+{{- range (include "helm-common.ports" . | fromYaml).ports -}}
+{{- if or (kindIs "invalid" .expose) .expose -}}
+{{- if eq .name "metrics" -}}
+{{- $named = .name -}}
+{{- else if and (not $numbered) (eq (toString .containerPort) $metricsPort) -}}
+{{- $numbered = .name -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- default $numbered $named -}}
+{{- end -}}
+
+{{/*
+Security context of the pods, the defaults of the securityPreset overridden by podSecurityContext
+*/}}
+{{- define "helm-common.podSecurityContext" -}}
+{{- include "helm-common.validateSecurityPreset" . -}}
+{{- $securityContext := dict -}}
+{{- if eq .Values.securityPreset "restricted" -}}
+{{- $securityContext = dict "runAsNonRoot" true "seccompProfile" (dict "type" "RuntimeDefault") -}}
+{{- else if eq .Values.securityPreset "baseline" -}}
+{{- $securityContext = dict "seccompProfile" (dict "type" "RuntimeDefault") -}}
+{{- end -}}
+{{- range $key, $value := .Values.podSecurityContext -}}
+{{- $_ := set $securityContext $key $value -}}
+{{- end -}}
+{{- toYaml $securityContext -}}
+{{- end -}}
+
+{{/*
+Security context of the containers, the defaults of the securityPreset overridden by securityContext
+*/}}
+{{- define "helm-common.containerSecurityContext" -}}
+{{- include "helm-common.validateSecurityPreset" . -}}
+{{- $securityContext := dict -}}
+{{- if eq .Values.securityPreset "restricted" -}}
+{{- $securityContext = dict "allowPrivilegeEscalation" false "capabilities" (dict "drop" (list "ALL")) "readOnlyRootFilesystem" true -}}
+{{- else if eq .Values.securityPreset "baseline" -}}
+{{- $securityContext = dict "allowPrivilegeEscalation" false "privileged" false -}}
+{{- end -}}
+{{- range $key, $value := .Values.securityContext -}}
+{{- $_ := set $securityContext $key $value -}}
+{{- end -}}
+{{- toYaml $securityContext -}}
+{{- end -}}
+
+{{- define "helm-common.validateSecurityPreset" -}}
+{{- $valid := list "restricted" "baseline" "none" -}}
+{{- if not (has .Values.securityPreset $valid) -}}
+{{- fail "Invalid securityPreset, must be one of (restricted,baseline,none)" -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Path of the env.vault secrets in the KV version 2 secrets engine
+*/}}
+{{- define "helm-common.vaultPath" -}}
+{{- include "helm-common.validateVaultInjector" . -}}
+{{- printf "%s/data/%s" .Values.vault.mount (tpl .Values.vault.path .) -}}
+{{- end -}}
+
+{{- define "helm-common.validateVaultInjector" -}}
+{{- $valid := list "bank-vaults" "vault-agent" -}}
+{{- if not (has .Values.vault.injector $valid) -}}
+{{- fail "Invalid vault.injector, must be one of (bank-vaults,vault-agent)" -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Pod annotations of the Vault injector. The Vault Agent renders a file per secret into /vault/secrets/,
+exporting the env.vault variables read from that secret
+*/}}
+{{- define "helm-common.vaultAnnotations" -}}
+{{- $context := .context -}}
+{{- $vaultPath := include "helm-common.vaultPath" $context -}}
+{{- $role := tpl $context.Values.vault.role $context -}}
+{{- if eq $context.Values.vault.injector "bank-vaults" }}
+vault.security.banzaicloud.io/vault-addr: {{ $context.Values.global.vaultAddress | quote }}
+vault.security.banzaicloud.io/vault-role: {{ $role | quote }}
+{{- else }}
+vault.hashicorp.com/agent-inject: "true"
+vault.hashicorp.com/service: {{ $context.Values.global.vaultAddress | quote }}
+vault.hashicorp.com/role: {{ $role | quote }}
+{{- $secrets := dict }}
+{{- range $key, $val := .vault }}
+{{- $secret := $val }}
+{{- $field := $key }}
+{{- if contains "#" $val }}
+{{- $secret = splitList "#" $val | first }}
+{{- $field = splitList "#" $val | last }}
+{{- end }}
+{{- $export := printf "export %s=\"{{ index .Data.data %q }}\"" $key $field }}
+{{- $_ := set $secrets $secret (append (default (list) (get $secrets $secret)) $export) }}
+{{- end }}
+{{- range $secret, $exports := $secrets }}
+{{- $file := replace "/" "-" $secret }}
+vault.hashicorp.com/agent-inject-secret-{{ $file }}: {{ printf "%s/%s" $vaultPath $secret | quote }}
+vault.hashicorp.com/agent-inject-template-{{ $file }}: {{ printf "{{- with secret \"%s/%s\" -}}\n%s\n{{- end }}" $vaultPath $secret (join "\n" $exports) | quote }}
+{{- end }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Renders a value given either as a tpl'd yaml string or as a list
+*/}}
+{{- define "helpers.tplOrToYaml" -}}
+{{- if kindIs "string" .value -}}
+{{- tpl .value .context | trim -}}
+{{- else -}}
+{{- toYaml .value -}}
+{{- end -}}
+{{- end -}}
+
 {{- define "helpers.list-env-variables" }}
 {{- if .Values.env }}
-{{- if .Values.appEnvSecret }}
-{{- $appSecretName := .Values.appEnvSecret.name -}}
-{{- range $key, $val := .Values.env.secret }}
+{{- include "helpers.list-env-variables-of" (dict "env" .Values.env "context" .) }}
+{{- end }}
+{{- end }}
+
+{{/*
+Environment variables of a container, resolving the normal, secret, configMap, fieldRef, resourceFieldRef,
+secretKeyRef, configMapKeyRef and vault entries of the given env dict. The vault entries are env vars only with Bank-Vaults,
+the Vault Agent renders them into files (see helm-common.vaultAnnotations)
+*/}}
+{{- define "helpers.list-env-variables-of" }}
+{{- $env := .env -}}
+{{- $context := .context -}}
+{{- if $context.Values.appEnvSecret }}
+{{- $appSecretName := include "helm-common.envSecretName" $context -}}
+{{- range $key, $val := $env.secret }}
 - name: {{ $key }}
   valueFrom:
     secretKeyRef:
@@ -63,9 +405,9 @@ app.kubernetes.io/instance: {{ .Release.Name }}
       key: {{ $key }}
 {{- end }}
 {{- end }}
-{{- if .Values.appEnvConfigMap }}
-{{- $appConfigmapName := .Values.appEnvConfigMap.name -}}
-{{- range $key, $val := .Values.env.configMap }}
+{{- if $context.Values.appEnvConfigMap }}
+{{- $appConfigmapName := include "helm-common.envConfigMapName" $context -}}
+{{- range $key, $val := $env.configMap }}
 - name: {{ $key }}
   valueFrom:
     configMapKeyRef:
@@ -73,13 +415,61 @@ app.kubernetes.io/instance: {{ .Release.Name }}
       key: {{ $key }}
 {{- end }}
 {{- end }}
-{{- range $key, $val := .Values.env.normal }}
+{{- range $key, $val := $env.normal }}
+- name: {{ $key }}
+  value: {{ tpl ( $val | quote ) $context }}
+{{- end }}
+{{- range $key, $val := $env.fieldRef }}
+- name: {{ $key }}
+  valueFrom:
+    fieldRef:
+      fieldPath: {{ $val }}
+{{- end }}
+{{- range $key, $val := $env.resourceFieldRef }}
 - name: {{ $key }}
-  value: {{ tpl ( $val | quote ) $ }}
+  valueFrom:
+    resourceFieldRef:
+      {{- if kindIs "string" $val }}
+      resource: {{ $val }}
+      {{- else }}
+      {{- toYaml $val | nindent 6 }}
+      {{- end }}
+{{- end }}
+{{- range $ref := list "secretKeyRef" "configMapKeyRef" }}
+{{- range $key, $val := default (dict) (get $env $ref) }}
+{{- if not (kindIs "map" $val) }}
+{{- fail (printf "Invalid env.%s.%s, name must be set" $ref $key) }}
+{{- end }}
+{{- if not $val.name }}
+{{- fail (printf "Invalid env.%s.%s, name must be set" $ref $key) }}
 {{- end }}
-{{- range $key, $val := .Values.env.vault }}
 - name: {{ $key }}
-  value: vault:k8s/data/{{ $.Release.Namespace }}/{{ $val }}
+  valueFrom:
+    {{ $ref }}:
+      name: {{ tpl $val.name $context }}
+      key: {{ default $key $val.key }}
+      {{- if hasKey $val "optional" }}
+      optional: {{ $val.optional }}
+      {{- end }}
+{{- end }}
+{{- end }}
+{{- if and $env.vault (eq $context.Values.vault.injector "bank-vaults") }}
+{{- $vaultPath := include "helm-common.vaultPath" $context -}}
+{{- range $key, $val := $env.vault }}
+- name: {{ $key }}
+  value: vault:{{ $vaultPath }}/{{ $val }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+envFrom sources of a container, names are tpl'd
+*/}}
+{{- define "helpers.list-env-from" }}
+{{- range .envFrom }}
+{{- if and (not .secretRef) (not .configMapRef) }}
+{{- fail "Invalid envFrom, secretRef or configMapRef must be set" }}
 {{- end }}
 {{- end }}
+{{- tpl (toYaml .envFrom) .context }}
 {{- end }}
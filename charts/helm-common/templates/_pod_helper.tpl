@@ -1,11 +1,10 @@
 {{ define "common.podSpec.containerPortsAndProbes" }}
 ports:
-- name: http
-  containerPort: {{ .Values.application.serverPort }}
-  protocol: TCP
-- name: health-check
-  containerPort: {{ .Values.application.managementPort }}
-  protocol: TCP
+{{- range (include "helm-common.ports" . | fromYaml).ports }}
+- name: {{ .name }}
+  containerPort: {{ .containerPort }}
+  protocol: {{ default "TCP" .protocol }}
+{{- end }}
 {{- if .Values.application.startupProbe.enabled }}
 startupProbe:
   {{- include "common.podSpec.probeTemplate" .Values.application.startupProbe }}
@@ -50,17 +49,51 @@ readinessProbe:
 {{- end }}
 
 {{ define "common.podSpec.mainPart" }}
+{{- $securityContext := include "helm-common.containerSecurityContext" . | fromYaml -}}
+{{- $configFiles := include "helm-common.configFiles" . | fromYaml -}}
+{{- $tmpVolume := $securityContext.readOnlyRootFilesystem -}}
+{{- range concat (default list .Values.initContainers) (default list .Values.sidecars) -}}
+{{- if and .securityContext .securityContext.readOnlyRootFilesystem -}}
+{{- $tmpVolume = true -}}
+{{- end -}}
+{{- end -}}
 {{- with .Values.imagePullSecrets -}}
 imagePullSecrets:
 {{- toYaml . | nindent 0 }}
 {{- end }}
-serviceAccountName: {{ default "default" .Values.global.serviceAccountName }}
+serviceAccountName: {{ include "helm-common.serviceAccountName" . }}
+{{- if not (kindIs "invalid" .Values.serviceAccount.automountServiceAccountToken) }}
+automountServiceAccountToken: {{ .Values.serviceAccount.automountServiceAccountToken }}
+{{- end }}
 terminationGracePeriodSeconds: {{ .Values.application.terminationGracePeriodSeconds }}
+{{- with include "helm-common.podSecurityContext" . | fromYaml }}
+securityContext: {{- toYaml . | nindent 2 }}
+{{- end }}
 volumes:
+{{- if $tmpVolume }}
+- name: tmp
+  emptyDir: {}
+{{- end }}
+{{- if $configFiles }}
+- name: config-files
+  configMap:
+    name: {{ include "helm-common.configFilesName" . }}
+{{- end }}
 {{- if .Values.extraVolumes }}
-{{- tpl .Values.extraVolumes . | trim | nindent 0 }}
+{{- include "helpers.tplOrToYaml" (dict "value" .Values.extraVolumes "context" .) | nindent 0 }}
 {{- end }}
 initContainers:
+{{- range .Values.sidecars }}
+{{- if .native }}
+{{- if not (semverCompare ">=1.29-0" $.Capabilities.KubeVersion.GitVersion) }}
+{{- fail (printf "Invalid sidecar %s, native sidecars require Kubernetes 1.29 or newer" .name) }}
+{{- end }}
+{{- include "common.podSpec.sidecar" (dict "sidecar" . "context" $) }}
+{{- end }}
+{{- end }}
+{{- range $index, $container := .Values.initContainers }}
+{{- include "common.podSpec.initContainer" (dict "container" $container "index" $index "context" $) }}
+{{- end }}
 {{- if .Values.extraInitContainers }}
 {{- tpl .Values.extraInitContainers . | trim | nindent 0 }}
 {{- end }}
@@ -78,17 +111,188 @@ containers:
   {{- end }}
   env:
   {{- include "helpers.list-env-variables" . | nindent 2 }}
+  {{- with .Values.envFrom }}
+  envFrom:
+  {{- include "helpers.list-env-from" (dict "envFrom" . "context" $) | nindent 2 }}
+  {{- end }}
   lifecycle:
   {{- if .Values.application.lifecycle }}
     {{- toYaml .Values.application.lifecycle | nindent 4 }}
   {{- end }}
   volumeMounts:
+    {{- if $securityContext.readOnlyRootFilesystem }}
+    - name: tmp
+      mountPath: /tmp
+    {{- end }}
+    {{- if $configFiles }}
+    {{- if .Values.configFiles.subPath }}
+    {{- range $key := concat (keys $configFiles.data) (keys $configFiles.binaryData) | sortAlpha }}
+    - name: config-files
+      mountPath: {{ printf "%s/%s" (trimSuffix "/" $.Values.configFiles.mountPath) $key }}
+      subPath: {{ $key }}
+      readOnly: true
+    {{- end }}
+    {{- else }}
+    - name: config-files
+      mountPath: {{ .Values.configFiles.mountPath }}
+      readOnly: true
+    {{- end }}
+    {{- end }}
     {{- if .Values.extraVolumeMounts }}
-    {{- tpl .Values.extraVolumeMounts . | trim | nindent 4 }}
+    {{- include "helpers.tplOrToYaml" (dict "value" .Values.extraVolumeMounts "context" .) | nindent 4 }}
     {{- end }}
+  {{- with $securityContext }}
+  securityContext: {{- toYaml . | nindent 4 }}
+  {{- end }}
   resources: {{- toYaml .Values.resources | nindent 4 }}
 {{- end -}}
 
+{{ define "common.podSpec.initContainer" }}
+{{- $container := .container -}}
+{{- $context := .context -}}
+{{- if not $container.name }}
+{{- fail (printf "Invalid initContainers[%d], name must be set" .index) }}
+{{- end }}
+{{- if and (not $container.image) (not $container.command) (not $container.args) }}
+{{- fail (printf "Invalid initContainer %s, command or args must be set when it runs the image of the application" $container.name) }}
+{{- end }}
+- name: {{ $container.name }}
+  image: {{ default (printf "%s:%s" $context.Values.image.repository (toString $context.Values.image.tag)) $container.image | quote }}
+  imagePullPolicy: {{ default $context.Values.image.pullPolicy $container.imagePullPolicy }}
+  {{- with $container.command }}
+  command:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $container.args }}
+  args:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- $env := $context.Values.env }}
+  {{- if hasKey $container "env" }}
+  {{- $env = $container.env }}
+  {{- end }}
+  {{- with $env }}
+  env:
+  {{- include "helpers.list-env-variables-of" (dict "env" . "context" $context) | nindent 2 }}
+  {{- end }}
+  {{- $envFrom := $context.Values.envFrom }}
+  {{- if hasKey $container "envFrom" }}
+  {{- $envFrom = $container.envFrom }}
+  {{- end }}
+  {{- with $envFrom }}
+  envFrom:
+  {{- include "helpers.list-env-from" (dict "envFrom" . "context" $context) | nindent 2 }}
+  {{- end }}
+  {{- $securityContext := include "helm-common.containerSecurityContext" $context | fromYaml }}
+  {{- range $key, $value := $container.securityContext }}
+  {{- $_ := set $securityContext $key $value }}
+  {{- end }}
+  {{- if or $container.volumeMounts $securityContext.readOnlyRootFilesystem }}
+  volumeMounts:
+    {{- if $securityContext.readOnlyRootFilesystem }}
+    - name: tmp
+      mountPath: /tmp
+    {{- end }}
+    {{- with $container.volumeMounts }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+  {{- with $securityContext }}
+  securityContext: {{- toYaml . | nindent 4 }}
+  {{- end }}
+  resources: {{- toYaml (default $context.Values.resources $container.resources) | nindent 4 }}
+  {{- with omit $container "name" "image" "imagePullPolicy" "command" "args" "env" "envFrom" "volumeMounts" "securityContext" "resources" }}
+  {{- toYaml . | nindent 2 }}
+  {{- end }}
+{{- end -}}
+
+{{ define "common.podSpec.sidecarContainers" }}
+{{- range .Values.sidecars }}
+{{- if not .native }}
+{{- include "common.podSpec.sidecar" (dict "sidecar" . "context" $) }}
+{{- end }}
+{{- end }}
+{{- end -}}
+
+{{ define "common.podSpec.sidecar" }}
+{{- $sidecar := .sidecar -}}
+{{- $context := .context -}}
+{{- if or (not $sidecar.name) (not $sidecar.image) }}
+{{- fail "Invalid sidecar, name and image must be set" }}
+{{- end }}
+- name: {{ $sidecar.name }}
+  image: {{ $sidecar.image | quote }}
+  imagePullPolicy: {{ default $context.Values.image.pullPolicy $sidecar.imagePullPolicy }}
+  {{- if $sidecar.native }}
+  restartPolicy: Always
+  {{- end }}
+  {{- with $sidecar.command }}
+  command:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $sidecar.args }}
+  args:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $sidecar.env }}
+  env:
+  {{- include "helpers.list-env-variables-of" (dict "env" . "context" $context) | nindent 2 }}
+  {{- end }}
+  {{- with $sidecar.envFrom }}
+  envFrom:
+  {{- include "helpers.list-env-from" (dict "envFrom" . "context" $context) | nindent 2 }}
+  {{- end }}
+  {{- with $sidecar.ports }}
+  ports:
+  {{- range . }}
+  - name: {{ .name }}
+    containerPort: {{ .containerPort }}
+    protocol: {{ default "TCP" .protocol }}
+  {{- end }}
+  {{- end }}
+  {{- with $sidecar.startupProbe }}
+  {{- if .enabled }}
+  startupProbe:
+    {{- include "common.podSpec.probeTemplate" . | indent 2 }}
+  {{- end }}
+  {{- end }}
+  {{- with $sidecar.liveness }}
+  {{- if .enabled }}
+  livenessProbe:
+    {{- include "common.podSpec.probeTemplate" . | indent 2 }}
+  {{- end }}
+  {{- end }}
+  {{- with $sidecar.readiness }}
+  {{- if .enabled }}
+  readinessProbe:
+    {{- include "common.podSpec.probeTemplate" . | indent 2 }}
+    {{- with .successThreshold }}
+    successThreshold: {{ . }}
+    {{- end }}
+  {{- end }}
+  {{- end }}
+  {{- $securityContext := include "helm-common.containerSecurityContext" $context | fromYaml }}
+  {{- range $key, $value := $sidecar.securityContext }}
+  {{- $_ := set $securityContext $key $value }}
+  {{- end }}
+  {{- if or $sidecar.volumeMounts $securityContext.readOnlyRootFilesystem }}
+  volumeMounts:
+    {{- if $securityContext.readOnlyRootFilesystem }}
+    - name: tmp
+      mountPath: /tmp
+    {{- end }}
+    {{- with $sidecar.volumeMounts }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+  {{- with $securityContext }}
+  securityContext: {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $sidecar.resources }}
+  resources: {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end -}}
+
 {{ define "common.podSpec.selectorsTolerationsAffinity" }}
 {{- with .Values.nodeSelector }}
 nodeSelector: {{- toYaml . | nindent 2 }}
@@ -102,7 +306,7 @@ tolerations: {{- toYaml . | nindent 2 }}
 {{- end -}}
 
 {{ define "common.podAnnotations" }}
-{{- if .Values.metrics.enabled }}
+{{- if and .Values.metrics.enabled .Values.metrics.scrapeAnnotations }}
 prometheus.io/scrape: {{ .Values.metrics.enabled | quote }}
 prometheus.io/port: {{ .Values.metrics.port | quote }}
 prometheus.io/path: {{ .Values.metrics.path | quote }}
@@ -110,9 +314,22 @@ prometheus.io/path: {{ .Values.metrics.path | quote }}
 {{- if .Values.defaultIpPool }}
 cni.projectcalico.org/ipv4pools: '["default-pool"]'
 {{- end }}
-{{- if .Values.env.vault }}
-vault.security.banzaicloud.io/vault-addr: {{ .Values.global.vaultAddress | quote }}
-vault.security.banzaicloud.io/vault-role: {{ .Release.Namespace | quote }}
+{{- $vault := deepCopy (default (dict) .Values.env.vault) }}
+{{- range .Values.sidecars }}
+{{- if and .env .env.vault }}
+{{- $vault = merge $vault .env.vault }}
+{{- end }}
+{{- end }}
+{{- if .Values.checksumAnnotations }}
+{{- with cat (include "common.app-env-configmap" .) (include "common.config-files" .) | trim }}
+checksum/config: {{ sha256sum . }}
+{{- end }}
+{{- with include "common.app-env-secret" . }}
+checksum/secret: {{ sha256sum . }}
+{{- end }}
+{{- end }}
+{{- if $vault }}
+{{- include "helm-common.vaultAnnotations" (dict "vault" $vault "context" .) }}
 {{- end }}
 {{- range $key, $value := .Values.podAnnotations }}
 {{ $key }}: {{ $value | quote }}